@@ -4,8 +4,19 @@ import (
 	"beli-mang/config"
 	"beli-mang/database"
 	"beli-mang/pkg/log"
+	"beli-mang/pkg/panics"
+	"beli-mang/repo"
 	"beli-mang/server"
+	"beli-mang/service"
 	"beli-mang/version"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -25,8 +36,43 @@ func Start(cfg *config.Config) {
 	}
 	defer db.Close()
 
-	s := server.NewServer(db, logger)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// background workers, stopped when ctx is done
+	var wg sync.WaitGroup
+	orderRepo := repo.NewOrderRepository(db)
+	reaper := service.NewDraftOrderReaper(cfg, orderRepo, repo.NewIdempotencyRepository(db), logger)
+	wg.Add(1)
+	go panics.CaptureGoroutine(func() {
+		defer wg.Done()
+		reaper.Run(ctx)
+	}, func() {})
+
+	// order events must stop before the server shutdown, it closes the open SSE streams
+	orderEvents := service.NewOrderEventService(cfg, orderRepo, repo.NewMerchantRepository(db), logger)
+	wg.Add(1)
+	go panics.CaptureGoroutine(func() {
+		defer wg.Done()
+		orderEvents.Run(ctx)
+	}, func() {})
+
+	s := server.NewServer(db, logger, orderEvents)
 	s.RegisterRoute(cfg)
 
-	logger.Fatal("failed run app", zap.Error(s.Start()))
+	go panics.CaptureGoroutine(func() {
+		if err := s.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatal("failed run app", zap.Error(err))
+		}
+	}, func() {})
+
+	<-ctx.Done()
+	logger.Info("shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.Shutdown(shutdownCtx); err != nil {
+		logger.Error("failed shutdown server", zap.Error(err))
+	}
+	wg.Wait()
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/sethvargo/go-envconfig"
@@ -18,6 +19,26 @@ type Config struct {
 	S3Bucket   string   `env:"AWS_S3_BUCKET_NAME"`
 	S3AcessKey string   `env:"AWS_ACCESS_KEY_ID"`
 	S3Secret   string   `env:"AWS_SECRET_ACCESS_KEY"`
+
+	// EstimateTTL is how long a calculated estimate can be confirmed into an order
+	EstimateTTL time.Duration `env:"ESTIMATE_TTL, default=15m"`
+	// IdempotencyTTL is how long a response is kept to be replayed for the same Idempotency-Key
+	IdempotencyTTL time.Duration `env:"IDEMPOTENCY_TTL, default=24h"`
+	// IdempotencyLease is how long a request in progress holds its Idempotency-Key,
+	// the key of a request that never finished can be taken over after it
+	IdempotencyLease time.Duration `env:"IDEMPOTENCY_LEASE, default=1m"`
+	// DefaultDeliveryRadiusKm is how far a merchant without its own delivery radius deliver
+	DefaultDeliveryRadiusKm float64 `env:"DEFAULT_DELIVERY_RADIUS_KM, default=3"`
+
+	DraftReaper DraftReaperConfig `env:", prefix=DRAFT_REAPER_"`
+	Fee         FeeConfig         `env:", prefix=FEE_"`
+	Routing     RoutingConfig     `env:", prefix=ROUTING_"`
+
+	// SpeedProfilesFile is a json file of the traffic speed profiles, see SpeedProfile.
+	// The free flow speed is used all the time when it is empty.
+	SpeedProfilesFile string `env:"SPEED_PROFILES_FILE"`
+	// SpeedProfiles is loaded from SpeedProfilesFile
+	SpeedProfiles []SpeedProfile
 }
 
 type DBConfig struct {
@@ -29,6 +50,34 @@ type DBConfig struct {
 	Params   string `env:"PARAMS"`
 }
 
+// DraftReaperConfig configure the background worker that clean up abandoned draft orders and expired idempotency keys
+type DraftReaperConfig struct {
+	Interval  time.Duration `env:"INTERVAL, default=10m"`
+	MaxAge    time.Duration `env:"MAX_AGE, default=24h"`
+	BatchSize int           `env:"BATCH_SIZE, default=500"`
+}
+
+// FeeConfig is the fee model added on top of the items price of an estimate
+type FeeConfig struct {
+	// Base and PerKm make the delivery fee, the distance is the length of the delivery route
+	Base  int     `env:"BASE, default=5000"`
+	PerKm float64 `env:"PER_KM, default=2000"`
+	// PerExtraMerchant is charged for every merchant after the first one
+	PerExtraMerchant int `env:"PER_EXTRA_MERCHANT, default=3000"`
+	// SmallOrder is charged when the items subtotal is below SmallOrderThreshold
+	SmallOrder          int `env:"SMALL_ORDER, default=2000"`
+	SmallOrderThreshold int `env:"SMALL_ORDER_THRESHOLD, default=25000"`
+}
+
+// RoutingConfig configure the road routing engine used for the delivery route,
+// straight line distance is used when URL is empty or the engine is down
+type RoutingConfig struct {
+	// URL of an OSRM compatible route service, e.g. http://localhost:5000
+	URL     string        `env:"URL"`
+	Profile string        `env:"PROFILE, default=driving"`
+	Timeout time.Duration `env:"TIMEOUT, default=2s"`
+}
+
 func Load(ctx context.Context) (*Config, error) {
 	// load .env file
 	err := godotenv.Load()
@@ -40,6 +89,9 @@ func Load(ctx context.Context) (*Config, error) {
 	if err := envconfig.Process(ctx, &cfg); err != nil {
 		return nil, err
 	}
+	if cfg.SpeedProfiles, err = loadSpeedProfiles(cfg.SpeedProfilesFile); err != nil {
+		return nil, err
+	}
 
 	return &cfg, nil
 }
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// HoursPerWeek is the number of hour-of-week slots, hour 0 is sunday 00:00 in the profile timezone
+const HoursPerWeek = 7 * 24
+
+// SpeedProfile is the courier speed through the week, e.g. slower on the weekday rush hours.
+// Zone limits the profile to the deliveries starting inside it, nil zone apply everywhere.
+type SpeedProfile struct {
+	Name     string      `json:"name"`
+	Timezone string      `json:"timezone"`
+	Zone     *SpeedZone  `json:"zone,omitempty"`
+	Slots    []SpeedSlot `json:"slots"`
+	// DefaultSpeedKmPerHour is used outside the slots, 0 means the free flow speed
+	DefaultSpeedKmPerHour float64 `json:"defaultSpeedKmPerHour,omitempty"`
+
+	location *time.Location
+}
+
+// Location returns the timezone of the profile hours
+func (p SpeedProfile) Location() *time.Location {
+	if p.location == nil {
+		return time.UTC
+	}
+	return p.location
+}
+
+// SpeedZone is a bounding box of coordinates
+type SpeedZone struct {
+	MinLat  float64 `json:"minLat"`
+	MaxLat  float64 `json:"maxLat"`
+	MinLong float64 `json:"minLong"`
+	MaxLong float64 `json:"maxLong"`
+}
+
+func (z SpeedZone) Contains(lat, long float64) bool {
+	return lat >= z.MinLat && lat <= z.MaxLat && long >= z.MinLong && long <= z.MaxLong
+}
+
+// SpeedSlot is the speed from FromHour until before ToHour, ToHour before or equal to FromHour
+// means the slot wraps to the next week
+type SpeedSlot struct {
+	FromHour       int     `json:"fromHour"`
+	ToHour         int     `json:"toHour"`
+	SpeedKmPerHour float64 `json:"speedKmPerHour"`
+	// FeeMultiplier scale the delivery fee in this slot, 0 means 1
+	FeeMultiplier float64 `json:"feeMultiplier,omitempty"`
+}
+
+func (s SpeedSlot) Contains(hourOfWeek int) bool {
+	if s.ToHour <= s.FromHour {
+		return hourOfWeek >= s.FromHour || hourOfWeek < s.ToHour
+	}
+	return hourOfWeek >= s.FromHour && hourOfWeek < s.ToHour
+}
+
+// loadSpeedProfiles read the json array of speed profiles in path, no file means no profile
+func loadSpeedProfiles(path string) ([]SpeedProfile, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read speed profiles: %w", err)
+	}
+	var profiles []SpeedProfile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("parse speed profiles %s: %w", path, err)
+	}
+
+	names := make(map[string]bool, len(profiles))
+	for i := range profiles {
+		p := &profiles[i]
+		if p.Name == "" || names[p.Name] {
+			return nil, fmt.Errorf("speed profile %d: name must be unique and not empty", i)
+		}
+		names[p.Name] = true
+		if p.location, err = time.LoadLocation(p.Timezone); err != nil {
+			return nil, fmt.Errorf("speed profile %s: %w", p.Name, err)
+		}
+		if p.DefaultSpeedKmPerHour < 0 {
+			return nil, fmt.Errorf("speed profile %s: defaultSpeedKmPerHour can't be negative", p.Name)
+		}
+		if p.Zone != nil && (p.Zone.MinLat > p.Zone.MaxLat || p.Zone.MinLong > p.Zone.MaxLong) {
+			return nil, fmt.Errorf("speed profile %s: zone min must not be above max", p.Name)
+		}
+		for j, slot := range p.Slots {
+			if slot.FromHour < 0 || slot.FromHour >= HoursPerWeek || slot.ToHour < 0 || slot.ToHour >= HoursPerWeek {
+				return nil, fmt.Errorf("speed profile %s slot %d: hours must be between 0 and %d", p.Name, j, HoursPerWeek-1)
+			}
+			if slot.SpeedKmPerHour <= 0 || slot.FeeMultiplier < 0 {
+				return nil, fmt.Errorf("speed profile %s slot %d: speedKmPerHour must be positive and feeMultiplier not negative", p.Name, j)
+			}
+		}
+	}
+	return profiles, nil
+}
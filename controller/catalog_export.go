@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"beli-mang/model"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+const (
+	// catalogFlushEvery is the number of rows written before flushing the response to the client
+	catalogFlushEvery = 500
+	// catalogStatusTrailer is "complete" or "truncated", sent after the body with the number of rows
+	// in catalogRowsTrailer, so a client can tell a cut export from a full one
+	catalogStatusTrailer = "X-Export-Status"
+	catalogRowsTrailer   = "X-Export-Rows"
+)
+
+var catalogCSVHeader = []string{
+	"merchantId", "merchantName", "merchantCategory", "merchantImageUrl", "lat", "long", "merchantCreatedAt",
+	"itemId", "itemName", "productCategory", "itemImageUrl", "price", "isAvailable", "itemCreatedAt",
+}
+
+// ExportCatalog stream the merchants and their items as NDJSON (default) or CSV.
+// Admin only exports the merchants they manage, super admin exports every merchant.
+// An export failing after the status is sent ends with an error line and the truncated status trailer.
+func (ctr *MerchantController) ExportCatalog(ctx echo.Context) error {
+	params, err := parseCatalogExportParams(ctx)
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, model.CreateMerchantGeneralResponse{Message: "params not valid", Error: err.Error()})
+	}
+	user := GetUserFromContext(ctx)
+	if user.Role != model.RoleSuperAdmin {
+		params.StaffId = user.Id
+	}
+
+	res := ctx.Response()
+	res.Header().Set("Trailer", catalogStatusTrailer+", "+catalogRowsTrailer)
+	var writeRow func(model.CatalogRow) error
+	var writeError func(message string) error
+	var flush func() error
+	switch params.Format {
+	case model.CatalogExportCSV:
+		res.Header().Set(echo.HeaderContentType, "text/csv")
+		res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="catalog.csv"`)
+		writer := csv.NewWriter(res)
+		if err := writer.Write(catalogCSVHeader); err != nil {
+			return err
+		}
+		writeRow = func(row model.CatalogRow) error {
+			return writer.Write(catalogCSVRecord(row))
+		}
+		// a single field record, it fails any reader expecting the header field count
+		writeError = func(message string) error {
+			return writer.Write([]string{"error: " + message})
+		}
+		flush = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+	default:
+		res.Header().Set(echo.HeaderContentType, "application/x-ndjson")
+		encoder := json.NewEncoder(res)
+		writeRow = func(row model.CatalogRow) error {
+			return encoder.Encode(row)
+		}
+		writeError = func(message string) error {
+			return encoder.Encode(model.CatalogExportError{Error: message})
+		}
+		flush = func() error {
+			return nil
+		}
+	}
+
+	written := 0
+	err = ctr.svc.ExportCatalog(ctx.Request().Context(), params, func(row model.CatalogRow) error {
+		if err := writeRow(row); err != nil {
+			return err
+		}
+		written++
+		if written%catalogFlushEvery == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+			res.Flush()
+		}
+		return nil
+	})
+	if err != nil && !res.Committed {
+		res.Header().Del("Trailer")
+		return ctx.JSON(errStatusCode(err), model.CreateMerchantGeneralResponse{Message: err.Error(), Error: err.Error()})
+	}
+	if err == nil {
+		err = flush()
+	}
+
+	status := "complete"
+	if err != nil {
+		// the status is already sent, tell the client the export is cut
+		status = "truncated"
+		ctr.logger.Error("[catalogExport] export truncated", zap.Int("rows", written), zap.Error(err))
+		if writeErr := writeError("export truncated: " + err.Error()); writeErr == nil {
+			_ = flush()
+		}
+	}
+	res.Header().Set(catalogStatusTrailer, status)
+	res.Header().Set(catalogRowsTrailer, strconv.Itoa(written))
+	res.Flush()
+	return nil
+}
+
+func catalogCSVRecord(row model.CatalogRow) []string {
+	m := row.Merchant
+	record := []string{
+		m.ID.String(), m.Name, string(m.Category), m.ImageURL,
+		strconv.FormatFloat(m.Location.Lat, 'f', -1, 64), strconv.FormatFloat(m.Location.Long, 'f', -1, 64),
+		m.CreatedAt.Format(time.RFC3339),
+		"", "", "", "", "", "", "",
+	}
+	if item := row.Item; item != nil {
+		copy(record[7:], []string{
+			item.ID.String(), item.Name, item.Category, item.ImageURL,
+			strconv.Itoa(item.Price), strconv.FormatBool(item.IsAvailable), item.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return record
+}
+
+func parseCatalogExportParams(ctx echo.Context) (model.CatalogExportParams, error) {
+	params := model.CatalogExportParams{
+		Format:           model.CatalogExportFormat(ctx.QueryParam("format")),
+		MerchantCategory: ctx.QueryParam("merchantCategory"),
+		ItemCategory:     ctx.QueryParam("productCategory"),
+	}
+	switch params.Format {
+	case "":
+		params.Format = model.CatalogExportNDJSON
+	case model.CatalogExportNDJSON, model.CatalogExportCSV:
+	default:
+		return params, fmt.Errorf("format must be %s or %s", model.CatalogExportNDJSON, model.CatalogExportCSV)
+	}
+
+	if value := ctx.QueryParam("merchantId"); value != "" {
+		merchantId, err := uuid.Parse(value)
+		if err != nil {
+			return params, fmt.Errorf("invalid merchantId: %w", err)
+		}
+		params.MerchantId = &merchantId
+	}
+	if value := ctx.QueryParam("createdAtFrom"); value != "" {
+		from, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return params, fmt.Errorf("createdAtFrom must be RFC3339: %w", err)
+		}
+		params.CreatedAtFrom = &from
+	}
+	if value := ctx.QueryParam("createdAtTo"); value != "" {
+		to, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return params, fmt.Errorf("createdAtTo must be RFC3339: %w", err)
+		}
+		params.CreatedAtTo = &to
+	}
+	return params, nil
+}
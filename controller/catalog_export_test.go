@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"beli-mang/model"
+	"beli-mang/service"
+	"context"
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// fakeCatalogSvc streams a number of catalog rows then returns err, it only implements the export
+type fakeCatalogSvc struct {
+	service.MerchantService
+	rows int
+	err  error
+}
+
+func (s *fakeCatalogSvc) ExportCatalog(ctx context.Context, params model.CatalogExportParams, fn func(model.CatalogRow) error) error {
+	for i := 0; i < s.rows; i++ {
+		if err := fn(model.CatalogRow{Merchant: model.Merchant{ID: uuid.New(), Name: "Warung"}}); err != nil {
+			return err
+		}
+	}
+	return s.err
+}
+
+func TestExportCatalogTruncation(t *testing.T) {
+	tests := []struct {
+		name       string
+		format     model.CatalogExportFormat
+		svc        *fakeCatalogSvc
+		wantCode   int
+		wantStatus string
+		wantRows   string
+		// wantLast is the prefix of the last line of the body
+		wantLast string
+	}{
+		{
+			name:       "complete ndjson",
+			format:     model.CatalogExportNDJSON,
+			svc:        &fakeCatalogSvc{rows: 3},
+			wantCode:   http.StatusOK,
+			wantStatus: "complete",
+			wantRows:   "3",
+			wantLast:   `{"merchant":`,
+		},
+		{
+			name:       "ndjson failing mid stream",
+			format:     model.CatalogExportNDJSON,
+			svc:        &fakeCatalogSvc{rows: 2, err: errors.New("connection reset")},
+			wantCode:   http.StatusOK,
+			wantStatus: "truncated",
+			wantRows:   "2",
+			wantLast:   `{"error":"export truncated: connection reset"}`,
+		},
+		{
+			name:       "csv failing after the first flush",
+			format:     model.CatalogExportCSV,
+			svc:        &fakeCatalogSvc{rows: catalogFlushEvery + 1, err: errors.New("connection reset")},
+			wantCode:   http.StatusOK,
+			wantStatus: "truncated",
+			wantRows:   "501",
+			wantLast:   "error: export truncated: connection reset",
+		},
+		{
+			name:     "failing before anything is sent",
+			format:   model.CatalogExportCSV,
+			svc:      &fakeCatalogSvc{err: errors.New("connection reset")},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/admin/catalog/export?format="+string(tt.format), nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.Set("userData", &model.JWTPayload{Role: model.RoleSuperAdmin})
+
+			ctr := NewMerchantController(tt.svc, validator.New(), zap.NewNop())
+			if err := ctr.ExportCatalog(ctx); err != nil {
+				t.Fatalf("ExportCatalog() error = %v", err)
+			}
+
+			res := rec.Result()
+			if res.StatusCode != tt.wantCode {
+				t.Fatalf("ExportCatalog() status = %d, want %d", res.StatusCode, tt.wantCode)
+			}
+			if tt.wantCode != http.StatusOK {
+				if got := res.Trailer.Get(catalogStatusTrailer); got != "" {
+					t.Errorf("ExportCatalog() error response has trailer %s", got)
+				}
+				return
+			}
+			if got := res.Trailer.Get(catalogStatusTrailer); got != tt.wantStatus {
+				t.Errorf("ExportCatalog() %s = %q, want %q", catalogStatusTrailer, got, tt.wantStatus)
+			}
+			if got := res.Trailer.Get(catalogRowsTrailer); got != tt.wantRows {
+				t.Errorf("ExportCatalog() %s = %q, want %q", catalogRowsTrailer, got, tt.wantRows)
+			}
+
+			lines := strings.Split(strings.TrimSuffix(rec.Body.String(), "\n"), "\n")
+			last := lines[len(lines)-1]
+			if !strings.HasPrefix(last, tt.wantLast) {
+				t.Errorf("ExportCatalog() last line = %s, want %s", last, tt.wantLast)
+			}
+			// the error record doesn't have the header field count, a csv reader fails on it
+			if tt.format == model.CatalogExportCSV && tt.wantStatus == "truncated" {
+				if _, err := csv.NewReader(rec.Body).ReadAll(); err == nil {
+					t.Errorf("csv ReadAll() of a truncated export error = nil")
+				}
+			}
+		})
+	}
+}
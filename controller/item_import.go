@@ -0,0 +1,210 @@
+package controller
+
+import (
+	"beli-mang/model"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ImportMerchantItems create many items from a CSV file or a JSON array in one transaction.
+// Every row is validated like CreateMerchantItem, when one row is invalid nothing is inserted.
+// Send dryRun=true to only get the validation report.
+func (ctr *MerchantController) ImportMerchantItems(ctx echo.Context) error {
+	merchantUUID, err := uuid.Parse(ctx.Param("merchantId"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, model.CreateMerchantGeneralResponse{Message: "merchant not found", Error: err.Error()})
+	}
+	dryRun, _ := strconv.ParseBool(ctx.QueryParam("dryRun"))
+
+	var rows []model.CreateMerchantItemRequest
+	var rowErrors []model.ImportItemRowError
+	mediaType, _, _ := mime.ParseMediaType(ctx.Request().Header.Get(echo.HeaderContentType))
+	switch mediaType {
+	case "text/csv":
+		rows, rowErrors, err = parseImportItemsCSV(ctx.Request().Body)
+	case echo.MIMEApplicationJSON:
+		rows, rowErrors, err = parseImportItemsJSON(ctx.Request().Body)
+	default:
+		return ctx.JSON(http.StatusUnsupportedMediaType, model.CreateMerchantGeneralResponse{Message: "content type must be text/csv or application/json"})
+	}
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, model.CreateMerchantGeneralResponse{Message: "request doesn’t pass validation", Error: err.Error()})
+	}
+	if len(rows) == 0 {
+		return ctx.JSON(http.StatusBadRequest, model.CreateMerchantGeneralResponse{Message: "request doesn’t pass validation", Error: "no rows to import"})
+	}
+
+	// row that can't be parsed is not validated again
+	parseFailed := make(map[int]bool, len(rowErrors))
+	for _, e := range rowErrors {
+		parseFailed[e.Row] = true
+	}
+	valid := make([]model.CreateMerchantItemRequest, 0, len(rows))
+	for i, row := range rows {
+		if parseFailed[i+1] {
+			continue
+		}
+		if errs := ctr.validateImportItemRow(i+1, row); len(errs) > 0 {
+			rowErrors = append(rowErrors, errs...)
+			continue
+		}
+		valid = append(valid, row)
+	}
+	sort.SliceStable(rowErrors, func(i, j int) bool {
+		return rowErrors[i].Row < rowErrors[j].Row
+	})
+
+	response := model.ImportMerchantItemsResponse{
+		DryRun:    dryRun,
+		TotalRows: len(rows),
+		ValidRows: len(valid),
+		Errors:    rowErrors,
+	}
+	if response.Errors == nil {
+		response.Errors = []model.ImportItemRowError{}
+	}
+	if len(rowErrors) > 0 && !dryRun {
+		return ctx.JSON(http.StatusBadRequest, model.MerchantGeneralResponse{
+			Message: "some rows don’t pass validation",
+			Data:    response,
+		})
+	}
+
+	itemIds, err := ctr.svc.ImportMerchantItems(ctx.Request().Context(), merchantUUID, valid, dryRun)
+	if err != nil {
+		return ctx.JSON(errStatusCode(err), model.CreateMerchantGeneralResponse{Message: err.Error(), Error: err.Error()})
+	}
+	if dryRun {
+		return ctx.JSON(http.StatusOK, model.MerchantGeneralResponse{
+			Message: "success",
+			Data:    response,
+		})
+	}
+
+	response.InsertedRows = len(itemIds)
+	response.ItemIds = itemIds
+	return ctx.JSON(http.StatusCreated, model.MerchantGeneralResponse{
+		Message: "success",
+		Data:    response,
+	})
+}
+
+func (ctr *MerchantController) validateImportItemRow(row int, request model.CreateMerchantItemRequest) []model.ImportItemRowError {
+	err := ctr.validate.Struct(request)
+	if err == nil {
+		return nil
+	}
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return []model.ImportItemRowError{{Row: row, Error: err.Error()}}
+	}
+
+	rowErrors := make([]model.ImportItemRowError, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		rowErrors = append(rowErrors, model.ImportItemRowError{
+			Row:   row,
+			Field: fe.Field(),
+			Error: fmt.Sprintf("failed on the '%s' tag", fe.Tag()),
+		})
+	}
+	return rowErrors
+}
+
+// parseImportItemsCSV read the rows by header name: name, productCategory, price, imageUrl and the optional prepTimeMinutes.
+// Row that can't be parsed is reported and replaced by an empty request to keep the row number.
+func parseImportItemsCSV(body io.Reader) ([]model.CreateMerchantItemRequest, []model.ImportItemRowError, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid csv header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"name", "productcategory", "price", "imageurl"} {
+		if _, ok := columns[name]; !ok {
+			return nil, nil, fmt.Errorf("missing csv column %s", name)
+		}
+	}
+
+	var rows []model.CreateMerchantItemRequest
+	var rowErrors []model.ImportItemRowError
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if len(rows) == model.MaxImportItemRows {
+			return nil, nil, fmt.Errorf("too many rows, max %d", model.MaxImportItemRows)
+		}
+		row := len(rows) + 1
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, nil, err
+			}
+			rows = append(rows, model.CreateMerchantItemRequest{})
+			rowErrors = append(rowErrors, model.ImportItemRowError{Row: row, Error: parseErr.Err.Error()})
+			continue
+		}
+
+		request := model.CreateMerchantItemRequest{
+			Name:            record[columns["name"]],
+			ProductCategory: record[columns["productcategory"]],
+			ImageURL:        record[columns["imageurl"]],
+		}
+		if price := strings.TrimSpace(record[columns["price"]]); price != "" {
+			request.Price, err = strconv.Atoi(price)
+			if err != nil {
+				rowErrors = append(rowErrors, model.ImportItemRowError{Row: row, Field: "Price", Error: "must be a number"})
+			}
+		}
+		if i, ok := columns["preptimeminutes"]; ok {
+			if prepTime := strings.TrimSpace(record[i]); prepTime != "" {
+				minutes, err := strconv.Atoi(prepTime)
+				if err != nil {
+					rowErrors = append(rowErrors, model.ImportItemRowError{Row: row, Field: "PrepTimeMinutes", Error: "must be a number"})
+				}
+				request.PrepTimeMinutes = &minutes
+			}
+		}
+		rows = append(rows, request)
+	}
+	return rows, rowErrors, nil
+}
+
+// parseImportItemsJSON decode each element separately, so a type error is reported on its own row
+func parseImportItemsJSON(body io.Reader) ([]model.CreateMerchantItemRequest, []model.ImportItemRowError, error) {
+	var raws []json.RawMessage
+	if err := json.NewDecoder(body).Decode(&raws); err != nil {
+		return nil, nil, fmt.Errorf("body must be a json array: %w", err)
+	}
+	if len(raws) > model.MaxImportItemRows {
+		return nil, nil, fmt.Errorf("too many rows, max %d", model.MaxImportItemRows)
+	}
+
+	rows := make([]model.CreateMerchantItemRequest, len(raws))
+	var rowErrors []model.ImportItemRowError
+	for i, raw := range raws {
+		if err := json.Unmarshal(raw, &rows[i]); err != nil {
+			rows[i] = model.CreateMerchantItemRequest{}
+			rowErrors = append(rowErrors, model.ImportItemRowError{Row: i + 1, Error: err.Error()})
+		}
+	}
+	return rows, rowErrors, nil
+}
@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"beli-mang/model"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+// rowErrorKeys returns the row and field of every error, the messages come from the parsers
+func rowErrorKeys(rowErrors []model.ImportItemRowError) []string {
+	keys := make([]string, 0, len(rowErrors))
+	for _, e := range rowErrors {
+		keys = append(keys, strings.TrimSpace(fmt.Sprintf("%d %s", e.Row, e.Field)))
+	}
+	return keys
+}
+
+func TestParseImportItemsCSV(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		want          []model.CreateMerchantItemRequest
+		wantRowErrors []string
+		wantErr       bool
+	}{
+		{
+			name: "header in any order and case",
+			body: "Price, imageUrl,NAME,productCategory\n" +
+				"15000,http://img.test/tea.png,Iced Tea,Beverage\n" +
+				"25000, http://img.test/rice.png,Fried Rice,Food\n",
+			want: []model.CreateMerchantItemRequest{
+				{Name: "Iced Tea", ProductCategory: "Beverage", ImageURL: "http://img.test/tea.png", Price: 15000},
+				{Name: "Fried Rice", ProductCategory: "Food", ImageURL: "http://img.test/rice.png", Price: 25000},
+			},
+		},
+		{
+			name: "optional prep time",
+			body: "name,productCategory,price,imageUrl,prepTimeMinutes\n" +
+				"Fried Rice,Food,25000,http://img.test/rice.png,15\n" +
+				"Iced Tea,Beverage,15000,http://img.test/tea.png,\n",
+			want: []model.CreateMerchantItemRequest{
+				{Name: "Fried Rice", ProductCategory: "Food", ImageURL: "http://img.test/rice.png", Price: 25000, PrepTimeMinutes: intPtr(15)},
+				{Name: "Iced Tea", ProductCategory: "Beverage", ImageURL: "http://img.test/tea.png", Price: 15000},
+			},
+		},
+		{
+			name: "numbers that don't parse are reported on their row",
+			body: "name,productCategory,price,imageUrl,prepTimeMinutes\n" +
+				"Fried Rice,Food,25000,http://img.test/rice.png,15\n" +
+				"Iced Tea,Beverage,cheap,http://img.test/tea.png,soon\n",
+			want: []model.CreateMerchantItemRequest{
+				{Name: "Fried Rice", ProductCategory: "Food", ImageURL: "http://img.test/rice.png", Price: 25000, PrepTimeMinutes: intPtr(15)},
+				{Name: "Iced Tea", ProductCategory: "Beverage", ImageURL: "http://img.test/tea.png", PrepTimeMinutes: intPtr(0)},
+			},
+			wantRowErrors: []string{"2 Price", "2 PrepTimeMinutes"},
+		},
+		{
+			name: "malformed row keeps the row numbers",
+			body: "name,productCategory,price,imageUrl\n" +
+				"Fried Rice,Food\n" +
+				"Iced Tea,Beverage,15000,http://img.test/tea.png\n",
+			want: []model.CreateMerchantItemRequest{
+				{},
+				{Name: "Iced Tea", ProductCategory: "Beverage", ImageURL: "http://img.test/tea.png", Price: 15000},
+			},
+			wantRowErrors: []string{"1"},
+		},
+		{
+			name:    "missing column",
+			body:    "name,productCategory,imageUrl\nFried Rice,Food,http://img.test/rice.png\n",
+			wantErr: true,
+		},
+		{
+			name:    "empty body",
+			body:    "",
+			wantErr: true,
+		},
+		{
+			name:    "too many rows",
+			body:    "name,productCategory,price,imageUrl\n" + strings.Repeat("Tea,Beverage,1000,http://img.test/tea.png\n", model.MaxImportItemRows+1),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, rowErrors, err := parseImportItemsCSV(strings.NewReader(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseImportItemsCSV() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(rows, tt.want) {
+				t.Errorf("parseImportItemsCSV() rows = %+v, want %+v", rows, tt.want)
+			}
+			if got := rowErrorKeys(rowErrors); len(got)+len(tt.wantRowErrors) > 0 && !reflect.DeepEqual(got, tt.wantRowErrors) {
+				t.Errorf("parseImportItemsCSV() row errors = %v, want %v", rowErrors, tt.wantRowErrors)
+			}
+		})
+	}
+}
+
+func TestParseImportItemsJSON(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		want          []model.CreateMerchantItemRequest
+		wantRowErrors []string
+		wantErr       bool
+	}{
+		{
+			name: "array of items",
+			body: `[{"name":"Fried Rice","productCategory":"Food","price":25000,"imageUrl":"http://img.test/rice.png","prepTimeMinutes":15},
+				{"name":"Iced Tea","productCategory":"Beverage","price":15000,"imageUrl":"http://img.test/tea.png"}]`,
+			want: []model.CreateMerchantItemRequest{
+				{Name: "Fried Rice", ProductCategory: "Food", ImageURL: "http://img.test/rice.png", Price: 25000, PrepTimeMinutes: intPtr(15)},
+				{Name: "Iced Tea", ProductCategory: "Beverage", ImageURL: "http://img.test/tea.png", Price: 15000},
+			},
+		},
+		{
+			name: "type error is reported on its own row",
+			body: `[{"name":"Fried Rice","productCategory":"Food","price":"cheap","imageUrl":"http://img.test/rice.png"},
+				{"name":"Iced Tea","productCategory":"Beverage","price":15000,"imageUrl":"http://img.test/tea.png"}]`,
+			want: []model.CreateMerchantItemRequest{
+				{},
+				{Name: "Iced Tea", ProductCategory: "Beverage", ImageURL: "http://img.test/tea.png", Price: 15000},
+			},
+			wantRowErrors: []string{"1"},
+		},
+		{
+			name:    "not an array",
+			body:    `{"name":"Fried Rice"}`,
+			wantErr: true,
+		},
+		{
+			name:    "too many rows",
+			body:    "[" + strings.TrimSuffix(strings.Repeat(`{},`, model.MaxImportItemRows+1), ",") + "]",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, rowErrors, err := parseImportItemsJSON(strings.NewReader(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseImportItemsJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(rows, tt.want) {
+				t.Errorf("parseImportItemsJSON() rows = %+v, want %+v", rows, tt.want)
+			}
+			if got := rowErrorKeys(rowErrors); len(got)+len(tt.wantRowErrors) > 0 && !reflect.DeepEqual(got, tt.wantRowErrors) {
+				t.Errorf("parseImportItemsJSON() row errors = %v, want %v", rowErrors, tt.wantRowErrors)
+			}
+		})
+	}
+}
@@ -10,19 +10,22 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
 )
 
 type MerchantController struct {
 	svc      service.MerchantService
 	validate *validator.Validate
+	logger   *zap.Logger
 }
 
-func NewMerchantController(svc service.MerchantService, validate *validator.Validate) *MerchantController {
+func NewMerchantController(svc service.MerchantService, validate *validator.Validate, logger *zap.Logger) *MerchantController {
 	_ = validate.RegisterValidation("custom_url", customURL)
 
 	return &MerchantController{
 		svc:      svc,
 		validate: validate,
+		logger:   logger,
 	}
 }
 
@@ -36,7 +39,9 @@ func (ctr *MerchantController) CreateMerchant(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, model.CreateMerchantGeneralResponse{Message: "request doesn’t pass validation", Error: err.Error()})
 	}
 
-	merchantId, err := ctr.svc.CreateMerchant(createMerchantRequest)
+	user := GetUserFromContext(ctx)
+	ownerId, _ := uuid.Parse(user.Id)
+	merchantId, err := ctr.svc.CreateMerchant(ctx.Request().Context(), createMerchantRequest, ownerId)
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, model.CreateMerchantGeneralResponse{Message: "Internal server error!", Error: err.Error()})
 	}
@@ -50,8 +55,15 @@ func (ctr *MerchantController) GetMerchant(ctx echo.Context) error {
 		return ctx.JSON(http.StatusBadRequest, echo.Map{"error": "params not valid"})
 	}
 
+	params := parseGetMerchantParams(value)
+	// super admin can see every merchant, other admin only the merchants they manage
+	user := GetUserFromContext(ctx)
+	if user.Role != model.RoleSuperAdmin {
+		params.StaffId = user.Id
+	}
+
 	// query to service
-	data, meta, err := ctr.svc.GetMerchant(ctx.Request().Context(), parseGetMerchantParams(value))
+	data, meta, err := ctr.svc.GetMerchant(ctx.Request().Context(), params)
 	if err != nil {
 		return ctx.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
 	}
@@ -112,6 +124,248 @@ func (ctr *MerchantController) GetMerchantItem(ctx echo.Context) error {
 	})
 }
 
+func (ctr *MerchantController) UpdateMerchant(ctx echo.Context) error {
+	merchantUUID, err := uuid.Parse(ctx.Param("merchantId"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, model.CreateMerchantGeneralResponse{Message: "merchant not found", Error: err.Error()})
+	}
+
+	var updateMerchantRequest model.UpdateMerchantRequest
+	if err := ctx.Bind(&updateMerchantRequest); err != nil {
+		return ctx.JSON(http.StatusBadRequest, model.CreateMerchantGeneralResponse{Message: "request doesn’t pass validation", Error: err.Error()})
+	}
+
+	if err := ctr.validate.Struct(updateMerchantRequest); err != nil {
+		return ctx.JSON(http.StatusBadRequest, model.CreateMerchantGeneralResponse{Message: "request doesn’t pass validation", Error: err.Error()})
+	}
+
+	merchant, err := ctr.svc.UpdateMerchant(ctx.Request().Context(), merchantUUID, updateMerchantRequest)
+	if err != nil {
+		return ctx.JSON(errStatusCode(err), model.CreateMerchantGeneralResponse{Message: err.Error(), Error: err.Error()})
+	}
+
+	return ctx.JSON(http.StatusOK, model.MerchantGeneralResponse{
+		Message: "success",
+		Data:    merchant,
+	})
+}
+
+func (ctr *MerchantController) DeleteMerchant(ctx echo.Context) error {
+	merchantUUID, err := uuid.Parse(ctx.Param("merchantId"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, model.CreateMerchantGeneralResponse{Message: "merchant not found", Error: err.Error()})
+	}
+
+	err = ctr.svc.DeleteMerchant(ctx.Request().Context(), merchantUUID)
+	if err != nil {
+		return ctx.JSON(errStatusCode(err), model.CreateMerchantGeneralResponse{Message: err.Error(), Error: err.Error()})
+	}
+
+	return ctx.JSON(http.StatusOK, model.MerchantGeneralResponse{
+		Message: "success",
+	})
+}
+
+func (ctr *MerchantController) UpdateMerchantItem(ctx echo.Context) error {
+	merchantUUID, err := uuid.Parse(ctx.Param("merchantId"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, model.CreateMerchantGeneralResponse{Message: "merchant not found", Error: err.Error()})
+	}
+	itemUUID, err := uuid.Parse(ctx.Param("itemId"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, model.CreateMerchantGeneralResponse{Message: "item not found", Error: err.Error()})
+	}
+
+	var updateMerchantItemRequest model.UpdateMerchantItemRequest
+	if err := ctx.Bind(&updateMerchantItemRequest); err != nil {
+		return ctx.JSON(http.StatusBadRequest, model.CreateMerchantGeneralResponse{Message: "request doesn’t pass validation", Error: err.Error()})
+	}
+
+	if err := ctr.validate.Struct(updateMerchantItemRequest); err != nil {
+		return ctx.JSON(http.StatusBadRequest, model.CreateMerchantGeneralResponse{Message: "request doesn’t pass validation", Error: err.Error()})
+	}
+
+	user := GetUserFromContext(ctx)
+	changedBy, _ := uuid.Parse(user.Id)
+	item, err := ctr.svc.UpdateMerchantItem(ctx.Request().Context(), merchantUUID, itemUUID, updateMerchantItemRequest, changedBy)
+	if err != nil {
+		return ctx.JSON(errStatusCode(err), model.CreateMerchantGeneralResponse{Message: err.Error(), Error: err.Error()})
+	}
+
+	return ctx.JSON(http.StatusOK, model.MerchantGeneralResponse{
+		Message: "success",
+		Data:    item,
+	})
+}
+
+func (ctr *MerchantController) DeleteMerchantItem(ctx echo.Context) error {
+	merchantUUID, err := uuid.Parse(ctx.Param("merchantId"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, model.CreateMerchantGeneralResponse{Message: "merchant not found", Error: err.Error()})
+	}
+	itemUUID, err := uuid.Parse(ctx.Param("itemId"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, model.CreateMerchantGeneralResponse{Message: "item not found", Error: err.Error()})
+	}
+
+	err = ctr.svc.DeleteMerchantItem(ctx.Request().Context(), merchantUUID, itemUUID)
+	if err != nil {
+		return ctx.JSON(errStatusCode(err), model.CreateMerchantGeneralResponse{Message: err.Error(), Error: err.Error()})
+	}
+
+	return ctx.JSON(http.StatusOK, model.MerchantGeneralResponse{
+		Message: "success",
+	})
+}
+
+func (ctr *MerchantController) GetItemPriceTimeline(ctx echo.Context) error {
+	merchantUUID, err := uuid.Parse(ctx.Param("merchantId"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, model.CreateMerchantGeneralResponse{Message: "merchant not found", Error: err.Error()})
+	}
+	itemUUID, err := uuid.Parse(ctx.Param("itemId"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, model.CreateMerchantGeneralResponse{Message: "item not found", Error: err.Error()})
+	}
+
+	prices, err := ctr.svc.GetItemPriceTimeline(ctx.Request().Context(), merchantUUID, itemUUID)
+	if err != nil {
+		return ctx.JSON(errStatusCode(err), model.CreateMerchantGeneralResponse{Message: err.Error(), Error: err.Error()})
+	}
+
+	return ctx.JSON(http.StatusOK, model.MerchantGeneralResponse{
+		Message: "success",
+		Data:    prices,
+	})
+}
+
+func (ctr *MerchantController) ScheduleItemPrice(ctx echo.Context) error {
+	merchantUUID, err := uuid.Parse(ctx.Param("merchantId"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, model.CreateMerchantGeneralResponse{Message: "merchant not found", Error: err.Error()})
+	}
+	itemUUID, err := uuid.Parse(ctx.Param("itemId"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, model.CreateMerchantGeneralResponse{Message: "item not found", Error: err.Error()})
+	}
+
+	var scheduleItemPriceRequest model.ScheduleItemPriceRequest
+	if err := ctx.Bind(&scheduleItemPriceRequest); err != nil {
+		return ctx.JSON(http.StatusBadRequest, model.CreateMerchantGeneralResponse{Message: "request doesn’t pass validation", Error: err.Error()})
+	}
+
+	if err := ctr.validate.Struct(scheduleItemPriceRequest); err != nil {
+		return ctx.JSON(http.StatusBadRequest, model.CreateMerchantGeneralResponse{Message: "request doesn’t pass validation", Error: err.Error()})
+	}
+
+	user := GetUserFromContext(ctx)
+	createdBy, _ := uuid.Parse(user.Id)
+	price, err := ctr.svc.ScheduleItemPrice(ctx.Request().Context(), merchantUUID, itemUUID, scheduleItemPriceRequest, createdBy)
+	if err != nil {
+		return ctx.JSON(errStatusCode(err), model.CreateMerchantGeneralResponse{Message: err.Error(), Error: err.Error()})
+	}
+
+	return ctx.JSON(http.StatusCreated, model.MerchantGeneralResponse{
+		Message: "success",
+		Data:    price,
+	})
+}
+
+func (ctr *MerchantController) GetItemOptionGroups(ctx echo.Context) error {
+	merchantUUID, err := uuid.Parse(ctx.Param("merchantId"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, model.CreateMerchantGeneralResponse{Message: "merchant not found", Error: err.Error()})
+	}
+	itemUUID, err := uuid.Parse(ctx.Param("itemId"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, model.CreateMerchantGeneralResponse{Message: "item not found", Error: err.Error()})
+	}
+
+	groups, err := ctr.svc.GetItemOptionGroups(ctx.Request().Context(), merchantUUID, itemUUID)
+	if err != nil {
+		return ctx.JSON(errStatusCode(err), model.CreateMerchantGeneralResponse{Message: err.Error(), Error: err.Error()})
+	}
+
+	return ctx.JSON(http.StatusOK, model.MerchantGeneralResponse{
+		Message: "success",
+		Data:    groups,
+	})
+}
+
+func (ctr *MerchantController) SetItemOptionGroups(ctx echo.Context) error {
+	merchantUUID, err := uuid.Parse(ctx.Param("merchantId"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, model.CreateMerchantGeneralResponse{Message: "merchant not found", Error: err.Error()})
+	}
+	itemUUID, err := uuid.Parse(ctx.Param("itemId"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, model.CreateMerchantGeneralResponse{Message: "item not found", Error: err.Error()})
+	}
+
+	var setItemOptionGroupsRequest model.SetItemOptionGroupsRequest
+	if err := ctx.Bind(&setItemOptionGroupsRequest); err != nil {
+		return ctx.JSON(http.StatusBadRequest, model.CreateMerchantGeneralResponse{Message: "request doesn’t pass validation", Error: err.Error()})
+	}
+
+	if err := ctr.validate.Struct(setItemOptionGroupsRequest); err != nil {
+		return ctx.JSON(http.StatusBadRequest, model.CreateMerchantGeneralResponse{Message: "request doesn’t pass validation", Error: err.Error()})
+	}
+
+	groups, err := ctr.svc.SetItemOptionGroups(ctx.Request().Context(), merchantUUID, itemUUID, setItemOptionGroupsRequest)
+	if err != nil {
+		return ctx.JSON(errStatusCode(err), model.CreateMerchantGeneralResponse{Message: err.Error(), Error: err.Error()})
+	}
+
+	return ctx.JSON(http.StatusOK, model.MerchantGeneralResponse{
+		Message: "success",
+		Data:    groups,
+	})
+}
+
+func (ctr *MerchantController) GetMerchantSchedule(ctx echo.Context) error {
+	merchantUUID, err := uuid.Parse(ctx.Param("merchantId"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, model.CreateMerchantGeneralResponse{Message: "merchant not found", Error: err.Error()})
+	}
+
+	schedule, err := ctr.svc.GetMerchantSchedule(ctx.Request().Context(), merchantUUID)
+	if err != nil {
+		return ctx.JSON(errStatusCode(err), model.CreateMerchantGeneralResponse{Message: err.Error(), Error: err.Error()})
+	}
+
+	return ctx.JSON(http.StatusOK, model.MerchantGeneralResponse{
+		Message: "success",
+		Data:    schedule,
+	})
+}
+
+func (ctr *MerchantController) UpdateMerchantSchedule(ctx echo.Context) error {
+	merchantUUID, err := uuid.Parse(ctx.Param("merchantId"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, model.CreateMerchantGeneralResponse{Message: "merchant not found", Error: err.Error()})
+	}
+
+	var schedule model.MerchantSchedule
+	if err := ctx.Bind(&schedule); err != nil {
+		return ctx.JSON(http.StatusBadRequest, model.CreateMerchantGeneralResponse{Message: "request doesn’t pass validation", Error: err.Error()})
+	}
+
+	if err := ctr.validate.Struct(schedule); err != nil {
+		return ctx.JSON(http.StatusBadRequest, model.CreateMerchantGeneralResponse{Message: "request doesn’t pass validation", Error: err.Error()})
+	}
+
+	schedule.MerchantId = merchantUUID
+	err = ctr.svc.UpdateMerchantSchedule(ctx.Request().Context(), schedule)
+	if err != nil {
+		return ctx.JSON(errStatusCode(err), model.CreateMerchantGeneralResponse{Message: err.Error(), Error: err.Error()})
+	}
+
+	return ctx.JSON(http.StatusOK, model.MerchantGeneralResponse{
+		Message: "success",
+		Data:    schedule,
+	})
+}
+
 func parseGetMerchantItemParams(params url.Values) model.GetMerchantItemParams {
 	var result model.GetMerchantItemParams
 
@@ -152,6 +406,8 @@ func parseGetMerchantParams(params url.Values) model.GetMerchantParams {
 			result.Name = values[0]
 		case "merchantCategory":
 			result.MerchantCategory = values[0]
+		case "availableOnly":
+			result.AvailableOnly = values[0] == "true"
 		case "limit":
 			limit, err := strconv.Atoi(values[0])
 			if err == nil {
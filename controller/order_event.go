@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"beli-mang/model"
+	cerr "beli-mang/pkg/customErr"
+	"beli-mang/service"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const sseHeartbeatInterval = 15 * time.Second
+
+type OrderEventController struct {
+	svc service.OrderEventService
+}
+
+func NewOrderEventController(svc service.OrderEventService) *OrderEventController {
+	return &OrderEventController{
+		svc: svc,
+	}
+}
+
+// StreamOrderEvents stream the order status transitions as server-sent events.
+// Client can resume by sending the last received event id in Last-Event-ID header.
+func (ctr *OrderEventController) StreamOrderEvents(ctx echo.Context) error {
+	orderID, err := uuid.Parse(ctx.Param("orderId"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, model.GeneralResponse{Message: "order not found", Error: err.Error()})
+	}
+
+	var lastSeq int64
+	lastEventID := ctx.Request().Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = ctx.QueryParam("lastEventId")
+	}
+	if lastEventID != "" {
+		lastSeq, err = strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil {
+			return ctx.JSON(http.StatusBadRequest, model.GeneralResponse{Message: "invalid Last-Event-ID", Error: err.Error()})
+		}
+	}
+
+	user := GetUserFromContext(ctx)
+	request := model.OrderEventsRequest{
+		OrderId: orderID,
+		Role:    user.Role,
+	}
+	request.UserId, _ = uuid.Parse(user.Id)
+
+	reqCtx := ctx.Request().Context()
+	// subscribe before reading the history, so no transition is missed in between
+	events, unsubscribe, err := ctr.svc.Subscribe(reqCtx, request)
+	if err != nil {
+		errCode := cerr.GetCode(err)
+		if errCode == 0 {
+			errCode = http.StatusInternalServerError
+		}
+		return ctx.JSON(errCode, model.GeneralResponse{
+			Message: err.Error(),
+		})
+	}
+	defer unsubscribe()
+
+	res := ctx.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	sendEvents := func() error {
+		history, err := ctr.svc.GetEventsAfter(reqCtx, orderID, lastSeq)
+		if err != nil {
+			return err
+		}
+		for _, h := range history {
+			data, err := json.Marshal(h)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(res, "id: %d\nevent: status\ndata: %s\n\n", h.Seq, data); err != nil {
+				return err
+			}
+			lastSeq = h.Seq
+		}
+		res.Flush()
+		return nil
+	}
+
+	if err := sendEvents(); err != nil {
+		return nil
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-reqCtx.Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				// server is shutting down or lost track of the events, client will reconnect with Last-Event-ID
+				return nil
+			}
+			if !event.Resync && event.Seq <= lastSeq {
+				continue
+			}
+			if err := sendEvents(); err != nil {
+				return nil
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(res, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+	}
+}
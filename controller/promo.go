@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"beli-mang/model"
+	"beli-mang/service"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type PromoController struct {
+	svc      service.PromoService
+	validate *validator.Validate
+}
+
+func NewPromoController(svc service.PromoService, validate *validator.Validate) *PromoController {
+	return &PromoController{
+		svc:      svc,
+		validate: validate,
+	}
+}
+
+func (ctr *PromoController) CreatePromoCode(ctx echo.Context) error {
+	var request model.CreatePromoCodeRequest
+	if err := ctx.Bind(&request); err != nil {
+		return ctx.JSON(http.StatusBadRequest, model.GeneralResponse{Message: "request doesn’t pass validation", Error: err.Error()})
+	}
+
+	if err := ctr.validate.Struct(request); err != nil {
+		return ctx.JSON(http.StatusBadRequest, model.GeneralResponse{Message: "request doesn’t pass validation", Error: err.Error()})
+	}
+
+	user := GetUserFromContext(ctx)
+	createdBy, _ := uuid.Parse(user.Id)
+	promo, err := ctr.svc.CreatePromoCode(ctx.Request().Context(), request, createdBy)
+	if err != nil {
+		return ctx.JSON(errStatusCode(err), model.GeneralResponse{Message: err.Error(), Error: err.Error()})
+	}
+
+	return ctx.JSON(http.StatusCreated, model.GeneralResponse{Message: "success", Data: promo})
+}
+
+func (ctr *PromoController) GetPromoCodes(ctx echo.Context) error {
+	value, err := ctx.FormParams()
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, echo.Map{"error": "params not valid"})
+	}
+
+	promos, err := ctr.svc.GetPromoCodes(ctx.Request().Context(), parsePromoCodeParams(value))
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, model.GeneralResponse{Message: err.Error(), Error: err.Error()})
+	}
+
+	return ctx.JSON(http.StatusOK, model.GeneralResponse{Message: "success", Data: promos})
+}
+
+func parsePromoCodeParams(params url.Values) model.PromoCodeParams {
+	result := model.PromoCodeParams{Limit: 5}
+	for key, values := range params {
+		switch key {
+		case "code":
+			result.Code = values[0]
+		case "limit":
+			limit, err := strconv.Atoi(values[0])
+			if err == nil && limit > 0 {
+				result.Limit = limit
+			}
+		case "offset":
+			offset, err := strconv.Atoi(values[0])
+			if err == nil && offset >= 0 {
+				result.Offset = offset
+			}
+		}
+	}
+
+	return result
+}
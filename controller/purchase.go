@@ -4,6 +4,7 @@ import (
 	"beli-mang/model"
 	cerr "beli-mang/pkg/customErr"
 	"beli-mang/service"
+	"context"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -53,6 +54,7 @@ func (ctr *PurchaseController) EstimateOrders(ctx echo.Context) error {
 		}
 		return ctx.JSON(errCode, model.GeneralResponse{
 			Message: err.Error(),
+			Data:    cerr.GetData(err),
 		})
 	}
 
@@ -68,15 +70,55 @@ func (ctr *PurchaseController) ConfirmOrder(ctx echo.Context) error {
 	if err := ctr.validate.Struct(payload); err != nil {
 		return ctx.JSON(http.StatusBadRequest, model.GeneralResponse{Message: "request doesn’t pass validation", Error: err.Error()})
 	}
+
+	user := GetUserFromContext(ctx)
+	payload.UserId, _ = uuid.Parse(user.Id)
 	data, err := ctr.svc.ConfirmOrder(ctx.Request().Context(), payload)
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, model.GeneralResponse{
+		errCode := cerr.GetCode(err)
+		if errCode == 0 {
+			errCode = http.StatusInternalServerError
+		}
+		return ctx.JSON(errCode, model.GeneralResponse{
 			Message: err.Error(),
+			Data:    cerr.GetData(err),
 		})
 	}
 	return ctx.JSON(http.StatusCreated, data)
 }
 
+func (ctr *PurchaseController) UpdateOrderStatus(ctx echo.Context) error {
+	orderID, err := uuid.Parse(ctx.Param("orderId"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, model.GeneralResponse{Message: "order not found", Error: err.Error()})
+	}
+
+	var payload model.UpdateOrderStatusRequest
+	if err := ctx.Bind(&payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, model.GeneralResponse{Message: "invalid format payload", Error: err.Error()})
+	}
+
+	if err := ctr.validate.Struct(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, model.GeneralResponse{Message: "request doesn’t pass validation", Error: err.Error()})
+	}
+
+	user := GetUserFromContext(ctx)
+	payload.OrderID = orderID
+	payload.ChangedBy, _ = uuid.Parse(user.Id)
+	payload.Role = user.Role
+	data, err := ctr.svc.UpdateOrderStatus(ctx.Request().Context(), payload)
+	if err != nil {
+		errCode := cerr.GetCode(err)
+		if errCode == 0 {
+			errCode = http.StatusInternalServerError
+		}
+		return ctx.JSON(errCode, model.GeneralResponse{
+			Message: err.Error(),
+		})
+	}
+	return ctx.JSON(http.StatusOK, data)
+}
+
 func (ctr *PurchaseController) GetUserOrders(ctx echo.Context) error {
 	var params model.UserOrdersParams
 	value, err := ctx.FormParams()
@@ -97,6 +139,68 @@ func (ctr *PurchaseController) GetUserOrders(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, data)
 }
 
+func (ctr *PurchaseController) GetOrderDetail(ctx echo.Context) error {
+	orderID, err := uuid.Parse(ctx.Param("orderId"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, model.GeneralResponse{Message: "order not found", Error: err.Error()})
+	}
+
+	user := GetUserFromContext(ctx)
+	request := model.GetOrderDetailRequest{
+		OrderId: orderID,
+		Role:    user.Role,
+	}
+	request.UserId, _ = uuid.Parse(user.Id)
+	data, err := ctr.svc.GetOrderDetail(ctx.Request().Context(), request)
+	if err != nil {
+		errCode := cerr.GetCode(err)
+		if errCode == 0 {
+			errCode = http.StatusInternalServerError
+		}
+		return ctx.JSON(errCode, model.GeneralResponse{
+			Message: err.Error(),
+		})
+	}
+	return ctx.JSON(http.StatusOK, data)
+}
+
+func (ctr *PurchaseController) Reorder(ctx echo.Context) error {
+	orderID, err := uuid.Parse(ctx.Param("orderId"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, model.GeneralResponse{Message: "order not found", Error: err.Error()})
+	}
+
+	var payload model.ReorderRequest
+	if err := ctx.Bind(&payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, model.GeneralResponse{Message: "invalid format payload", Error: err.Error()})
+	}
+
+	if payload.UserLocation != nil {
+		if err := ValidateLatitude(payload.UserLocation.Lat); err != nil {
+			return ctx.JSON(http.StatusBadRequest, model.GeneralResponse{Message: "request doesn’t pass validation", Error: err.Error()})
+		}
+		if err := ValidateLongitude(payload.UserLocation.Long); err != nil {
+			return ctx.JSON(http.StatusBadRequest, model.GeneralResponse{Message: "request doesn’t pass validation", Error: err.Error()})
+		}
+	}
+
+	user := GetUserFromContext(ctx)
+	payload.OrderId = orderID
+	payload.UserId, _ = uuid.Parse(user.Id)
+	data, err := ctr.svc.Reorder(ctx.Request().Context(), payload)
+	if err != nil {
+		errCode := cerr.GetCode(err)
+		if errCode == 0 {
+			errCode = http.StatusInternalServerError
+		}
+		return ctx.JSON(errCode, model.GeneralResponse{
+			Message: err.Error(),
+			Data:    cerr.GetData(err),
+		})
+	}
+	return ctx.JSON(http.StatusOK, data)
+}
+
 func (ctr *PurchaseController) GetMerchantNearby(ctx echo.Context) error {
 	latlong := ctx.Param("latlong")
 	temp := strings.Split(latlong, ",")
@@ -128,6 +232,155 @@ func (ctr *PurchaseController) GetMerchantNearby(ctx echo.Context) error {
 	})
 }
 
+func (ctr *PurchaseController) GetMerchantOrders(ctx echo.Context) error {
+	merchantID, err := uuid.Parse(ctx.Param("merchantId"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, model.GeneralResponse{Message: "merchant not found", Error: err.Error()})
+	}
+
+	value, err := ctx.FormParams()
+	if err != nil {
+		return ctx.JSON(http.StatusBadRequest, echo.Map{"error": "params not valid"})
+	}
+
+	params := parseMerchantOrdersParams(value)
+	params.MerchantId = merchantID
+	data, err := ctr.svc.GetMerchantOrders(ctx.Request().Context(), params)
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, model.GeneralResponse{
+			Message: err.Error(),
+		})
+	}
+	return ctx.JSON(http.StatusOK, data)
+}
+
+func (ctr *PurchaseController) AcceptMerchantOrder(ctx echo.Context) error {
+	return ctr.decideMerchantOrder(ctx, ctr.svc.AcceptMerchantOrder)
+}
+
+func (ctr *PurchaseController) RejectMerchantOrder(ctx echo.Context) error {
+	return ctr.decideMerchantOrder(ctx, ctr.svc.RejectMerchantOrder)
+}
+
+func (ctr *PurchaseController) decideMerchantOrder(ctx echo.Context, decide func(context.Context, model.MerchantOrderActionRequest) (model.MerchantOrderActionResponse, error)) error {
+	merchantID, err := uuid.Parse(ctx.Param("merchantId"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, model.GeneralResponse{Message: "merchant not found", Error: err.Error()})
+	}
+	orderID, err := uuid.Parse(ctx.Param("orderId"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, model.GeneralResponse{Message: "order not found", Error: err.Error()})
+	}
+
+	user := GetUserFromContext(ctx)
+	request := model.MerchantOrderActionRequest{
+		MerchantId: merchantID,
+		OrderId:    orderID,
+	}
+	request.ChangedBy, _ = uuid.Parse(user.Id)
+	data, err := decide(ctx.Request().Context(), request)
+	if err != nil {
+		errCode := cerr.GetCode(err)
+		if errCode == 0 {
+			errCode = http.StatusInternalServerError
+		}
+		return ctx.JSON(errCode, model.GeneralResponse{
+			Message: err.Error(),
+		})
+	}
+	return ctx.JSON(http.StatusOK, data)
+}
+
+func (ctr *PurchaseController) CancelUserOrder(ctx echo.Context) error {
+	orderID, err := uuid.Parse(ctx.Param("orderId"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, model.GeneralResponse{Message: "order not found", Error: err.Error()})
+	}
+
+	var payload model.CancelOrderRequest
+	if err := ctx.Bind(&payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, model.GeneralResponse{Message: "invalid format payload", Error: err.Error()})
+	}
+
+	if err := ctr.validate.Struct(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, model.GeneralResponse{Message: "request doesn’t pass validation", Error: err.Error()})
+	}
+
+	user := GetUserFromContext(ctx)
+	payload.OrderId = orderID
+	payload.CancelledBy, _ = uuid.Parse(user.Id)
+	data, err := ctr.svc.CancelUserOrder(ctx.Request().Context(), payload)
+	if err != nil {
+		errCode := cerr.GetCode(err)
+		if errCode == 0 {
+			errCode = http.StatusInternalServerError
+		}
+		return ctx.JSON(errCode, model.GeneralResponse{
+			Message: err.Error(),
+		})
+	}
+	return ctx.JSON(http.StatusOK, data)
+}
+
+func (ctr *PurchaseController) CancelMerchantOrder(ctx echo.Context) error {
+	merchantID, err := uuid.Parse(ctx.Param("merchantId"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, model.GeneralResponse{Message: "merchant not found", Error: err.Error()})
+	}
+	orderID, err := uuid.Parse(ctx.Param("orderId"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, model.GeneralResponse{Message: "order not found", Error: err.Error()})
+	}
+
+	var payload model.CancelOrderRequest
+	if err := ctx.Bind(&payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, model.GeneralResponse{Message: "invalid format payload", Error: err.Error()})
+	}
+
+	if err := ctr.validate.Struct(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, model.GeneralResponse{Message: "request doesn’t pass validation", Error: err.Error()})
+	}
+
+	user := GetUserFromContext(ctx)
+	payload.OrderId = orderID
+	payload.MerchantId = merchantID
+	payload.CancelledBy, _ = uuid.Parse(user.Id)
+	payload.Role = user.Role
+	data, err := ctr.svc.CancelMerchantOrder(ctx.Request().Context(), payload)
+	if err != nil {
+		errCode := cerr.GetCode(err)
+		if errCode == 0 {
+			errCode = http.StatusInternalServerError
+		}
+		return ctx.JSON(errCode, model.GeneralResponse{
+			Message: err.Error(),
+		})
+	}
+	return ctx.JSON(http.StatusOK, data)
+}
+
+func parseMerchantOrdersParams(params url.Values) model.MerchantOrdersParams {
+	var result model.MerchantOrdersParams
+	for key, values := range params {
+		switch key {
+		case "status":
+			result.Status = model.OrderStatus(values[0])
+		case "limit":
+			limit, err := strconv.Atoi(values[0])
+			if err == nil {
+				result.Limit = limit
+			}
+		case "offset":
+			offset, err := strconv.Atoi(values[0])
+			if err == nil {
+				result.Offset = offset
+			}
+		}
+	}
+
+	return result
+}
+
 func parseUserOrdersParams(params url.Values) model.UserOrdersParams {
 	var result model.UserOrdersParams
 	for key, values := range params {
@@ -138,6 +391,11 @@ func parseUserOrdersParams(params url.Values) model.UserOrdersParams {
 		case "status":
 			s := model.OrderStatus(values[0])
 			result.Status = s
+		case "merchantId":
+			merchantID, err := uuid.Parse(values[0])
+			if err == nil {
+				result.MerchantId = &merchantID
+			}
 		case "merchantCategory":
 			v := model.MerchantCategory(values[0])
 			result.MerchantCategory = &v
@@ -162,3 +420,20 @@ func parseUserOrdersParams(params url.Values) model.UserOrdersParams {
 
 	return result
 }
+
+func (ctr *PurchaseController) PreviewSpeedProfiles(ctx echo.Context) error {
+	var payload model.SpeedProfilePreviewRequest
+	if err := ctx.Bind(&payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, model.GeneralResponse{Message: "invalid format payload", Error: err.Error()})
+	}
+
+	if err := ctr.validate.Struct(payload); err != nil {
+		return ctx.JSON(http.StatusBadRequest, model.GeneralResponse{Message: "request doesn’t pass validation", Error: err.Error()})
+	}
+
+	data, err := ctr.svc.PreviewSpeedProfiles(ctx.Request().Context(), payload)
+	if err != nil {
+		return ctx.JSON(errStatusCode(err), model.GeneralResponse{Message: err.Error(), Error: err.Error()})
+	}
+	return ctx.JSON(http.StatusOK, data)
+}
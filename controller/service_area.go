@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"beli-mang/model"
+	"beli-mang/service"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+type ServiceAreaController struct {
+	svc      service.ServiceAreaService
+	validate *validator.Validate
+}
+
+func NewServiceAreaController(svc service.ServiceAreaService, validate *validator.Validate) *ServiceAreaController {
+	return &ServiceAreaController{
+		svc:      svc,
+		validate: validate,
+	}
+}
+
+func (ctr *ServiceAreaController) CreateServiceArea(ctx echo.Context) error {
+	var request model.CreateServiceAreaRequest
+	if err := ctx.Bind(&request); err != nil {
+		return ctx.JSON(http.StatusBadRequest, model.GeneralResponse{Message: "request doesn’t pass validation", Error: err.Error()})
+	}
+
+	if err := ctr.validate.Struct(request); err != nil {
+		return ctx.JSON(http.StatusBadRequest, model.GeneralResponse{Message: "request doesn’t pass validation", Error: err.Error()})
+	}
+
+	area, err := ctr.svc.CreateServiceArea(ctx.Request().Context(), request)
+	if err != nil {
+		return ctx.JSON(errStatusCode(err), model.GeneralResponse{Message: err.Error(), Error: err.Error()})
+	}
+
+	return ctx.JSON(http.StatusCreated, model.GeneralResponse{Message: "success", Data: area})
+}
+
+func (ctr *ServiceAreaController) GetServiceAreas(ctx echo.Context) error {
+	areas, err := ctr.svc.GetServiceAreas(ctx.Request().Context())
+	if err != nil {
+		return ctx.JSON(http.StatusInternalServerError, model.GeneralResponse{Message: err.Error(), Error: err.Error()})
+	}
+
+	return ctx.JSON(http.StatusOK, model.GeneralResponse{Message: "success", Data: areas})
+}
+
+func (ctr *ServiceAreaController) DeleteServiceArea(ctx echo.Context) error {
+	id, err := uuid.Parse(ctx.Param("serviceAreaId"))
+	if err != nil {
+		return ctx.JSON(http.StatusNotFound, model.GeneralResponse{Message: "service area not found", Error: err.Error()})
+	}
+
+	err = ctr.svc.DeleteServiceArea(ctx.Request().Context(), id)
+	if err != nil {
+		return ctx.JSON(errStatusCode(err), model.GeneralResponse{Message: err.Error(), Error: err.Error()})
+	}
+
+	return ctx.JSON(http.StatusOK, model.GeneralResponse{Message: "success"})
+}
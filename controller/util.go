@@ -2,6 +2,9 @@ package controller
 
 import (
 	"beli-mang/model"
+	cerr "beli-mang/pkg/customErr"
+	"net/http"
+
 	"github.com/labstack/echo/v4"
 )
 
@@ -9,3 +12,12 @@ func GetUserFromContext(c echo.Context) *model.JWTPayload {
 	jwtPayload := c.Get("userData")
 	return jwtPayload.(*model.JWTPayload)
 }
+
+// errStatusCode returns the status code of a custom error, or 500 for any other error
+func errStatusCode(err error) int {
+	errCode := cerr.GetCode(err)
+	if errCode == 0 {
+		errCode = http.StatusInternalServerError
+	}
+	return errCode
+}
@@ -0,0 +1,8 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_order_status_history_order_id;
+
+-- Drop the orderStatusHistory table
+DROP TABLE IF EXISTS "orderStatusHistory";
+
+-- Note: postgres can't drop a value from an enum type,
+-- the added "orderStatus" values are left as is.
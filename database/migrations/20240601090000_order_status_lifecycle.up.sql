@@ -0,0 +1,23 @@
+ALTER TYPE "orderStatus" ADD VALUE IF NOT EXISTS 'ACCEPTED';
+ALTER TYPE "orderStatus" ADD VALUE IF NOT EXISTS 'PREPARING';
+ALTER TYPE "orderStatus" ADD VALUE IF NOT EXISTS 'PICKED_UP';
+ALTER TYPE "orderStatus" ADD VALUE IF NOT EXISTS 'DELIVERED';
+ALTER TYPE "orderStatus" ADD VALUE IF NOT EXISTS 'CANCELLED';
+ALTER TYPE "orderStatus" ADD VALUE IF NOT EXISTS 'REJECTED';
+
+
+CREATE TABLE IF NOT EXISTS "orderStatusHistory" (
+      "id" UUID NOT NULL PRIMARY KEY,
+      "orderId" UUID NOT NULL,
+      "fromStatus" "orderStatus",
+      "toStatus" "orderStatus" NOT NULL,
+      "changedBy" UUID NOT NULL,
+      "createdAt" TIMESTAMP NOT NULL,
+      CONSTRAINT fk_orderStatusHistory_orderId
+          FOREIGN KEY("orderId")
+              REFERENCES "order"("orderId")
+              ON DELETE CASCADE
+);
+
+-- Index on orderId, history is always read per order ordered by time
+CREATE INDEX IF NOT EXISTS idx_order_status_history_order_id ON "orderStatusHistory" ("orderId", "createdAt");
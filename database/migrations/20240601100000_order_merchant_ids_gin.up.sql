@@ -0,0 +1,2 @@
+-- GIN index on merchantIds so merchant inbox can use array containment (@>) lookup
+CREATE INDEX IF NOT EXISTS idx_order_merchant_ids_gin ON "order" USING GIN ("merchantIds");
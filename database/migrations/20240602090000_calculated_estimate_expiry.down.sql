@@ -0,0 +1,4 @@
+ALTER TABLE "calculatedEstimate"
+    DROP COLUMN IF EXISTS "userId",
+    DROP COLUMN IF EXISTS "expiresAt",
+    DROP COLUMN IF EXISTS "confirmedAt";
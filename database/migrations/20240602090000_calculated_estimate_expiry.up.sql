@@ -0,0 +1,15 @@
+ALTER TABLE "calculatedEstimate"
+    ADD COLUMN IF NOT EXISTS "userId" UUID,
+    ADD COLUMN IF NOT EXISTS "expiresAt" TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS "confirmedAt" TIMESTAMP;
+
+-- backfill existing estimate, owner is taken from the order and they are considered already expired
+UPDATE "calculatedEstimate" ce
+SET "userId" = o."userId",
+    "expiresAt" = ce."createdAt"
+FROM "order" o
+WHERE o."orderId" = ce."orderId";
+
+ALTER TABLE "calculatedEstimate"
+    ALTER COLUMN "userId" SET NOT NULL,
+    ALTER COLUMN "expiresAt" SET NOT NULL;
@@ -0,0 +1,8 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_refund_ledger_order_id;
+
+-- Drop the refundLedger table
+DROP TABLE IF EXISTS "refundLedger";
+
+DROP TYPE IF EXISTS "cancelInitiator";
+DROP TYPE IF EXISTS "cancelReason";
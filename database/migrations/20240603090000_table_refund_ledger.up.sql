@@ -0,0 +1,36 @@
+CREATE TYPE "cancelReason" AS ENUM (
+  'CHANGED_MIND',
+  'ORDERED_BY_MISTAKE',
+  'DELIVERY_TOO_LONG',
+  'OUT_OF_STOCK',
+  'MERCHANT_CLOSED',
+  'MERCHANT_TOO_BUSY',
+  'OTHER'
+);
+
+CREATE TYPE "cancelInitiator" AS ENUM (
+  'user',
+  'merchant'
+);
+
+
+CREATE TABLE IF NOT EXISTS "refundLedger" (
+      "id" UUID NOT NULL PRIMARY KEY,
+      "orderId" UUID NOT NULL,
+      "calculatedEstimateId" UUID NOT NULL,
+      "amount" INTEGER NOT NULL,
+      "reasonCode" "cancelReason" NOT NULL,
+      "note" VARCHAR NOT NULL DEFAULT '',
+      "initiator" "cancelInitiator" NOT NULL,
+      "initiatedBy" UUID NOT NULL,
+      "createdAt" TIMESTAMP NOT NULL,
+      CONSTRAINT fk_refundLedger_orderId
+          FOREIGN KEY("orderId")
+              REFERENCES "order"("orderId"),
+      CONSTRAINT fk_refundLedger_calculatedEstimateId
+          FOREIGN KEY("calculatedEstimateId")
+              REFERENCES "calculatedEstimate"("calculatedEstimateId")
+);
+
+-- Index on orderId
+CREATE INDEX IF NOT EXISTS idx_refund_ledger_order_id ON "refundLedger" ("orderId");
@@ -0,0 +1,5 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_idempotency_key_expires_at;
+
+-- Drop the idempotencyKey table
+DROP TABLE IF EXISTS "idempotencyKey";
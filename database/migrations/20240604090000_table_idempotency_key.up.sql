@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS "idempotencyKey" (
+      "key" VARCHAR NOT NULL,
+      "userId" UUID NOT NULL,
+      "requestHash" VARCHAR NOT NULL,
+      "statusCode" INTEGER, -- null while the first request is still in progress
+      "contentType" VARCHAR NOT NULL DEFAULT '',
+      "responseBody" BYTEA,
+      "createdAt" TIMESTAMP NOT NULL,
+      "expiresAt" TIMESTAMP NOT NULL,
+      PRIMARY KEY ("userId", "key")
+);
+
+-- Index on expiresAt to clean up expired keys
+CREATE INDEX IF NOT EXISTS idx_idempotency_key_expires_at ON "idempotencyKey" ("expiresAt");
@@ -0,0 +1,2 @@
+-- Partial index to find abandoned draft orders by age
+CREATE INDEX IF NOT EXISTS idx_order_draft_created_at ON "order" ("createdAt") WHERE "orderStatus" = 'DRAFT';
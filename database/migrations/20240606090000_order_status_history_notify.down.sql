@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS trg_order_status_history_notify ON "orderStatusHistory";
+DROP FUNCTION IF EXISTS notify_order_status_history();
+
+-- Drop indexes
+DROP INDEX IF EXISTS idx_order_status_history_order_id_seq;
+
+ALTER TABLE "orderStatusHistory" DROP COLUMN IF EXISTS "seq";
@@ -0,0 +1,16 @@
+-- Monotonic sequence used as SSE event id, so a client can resume after the last event it saw
+ALTER TABLE "orderStatusHistory" ADD COLUMN IF NOT EXISTS "seq" BIGSERIAL;
+
+CREATE INDEX IF NOT EXISTS idx_order_status_history_order_id_seq ON "orderStatusHistory" ("orderId", "seq");
+
+-- Notify every listening instance once the status change is committed
+CREATE OR REPLACE FUNCTION notify_order_status_history() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('order_status', json_build_object('orderId', NEW."orderId", 'seq', NEW."seq")::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_order_status_history_notify
+    AFTER INSERT ON "orderStatusHistory"
+    FOR EACH ROW EXECUTE FUNCTION notify_order_status_history();
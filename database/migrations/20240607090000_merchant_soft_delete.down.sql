@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "merchant_active_created_at_idx";
+
+ALTER TABLE "merchant" DROP COLUMN IF EXISTS "deletedAt";
@@ -0,0 +1,4 @@
+ALTER TABLE "merchant" ADD COLUMN IF NOT EXISTS "deletedAt" TIMESTAMP;
+
+-- Most of the query only read active merchant
+CREATE INDEX IF NOT EXISTS "merchant_active_created_at_idx" ON merchant ("createdAt") WHERE "deletedAt" IS NULL;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "merchant_item_merchant_id_idx";
+
+ALTER TABLE "merchantItem"
+    DROP COLUMN IF EXISTS "isAvailable",
+    DROP COLUMN IF EXISTS "deletedAt";
@@ -0,0 +1,6 @@
+ALTER TABLE "merchantItem"
+    ADD COLUMN IF NOT EXISTS "isAvailable" BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN IF NOT EXISTS "deletedAt" TIMESTAMP;
+
+-- Index on merchantId, items are always listed per merchant
+CREATE INDEX IF NOT EXISTS "merchant_item_merchant_id_idx" ON "merchantItem" ("merchantId") WHERE "deletedAt" IS NULL;
@@ -0,0 +1,8 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_merchant_staff_staff_id;
+
+-- Drop the merchantStaff table
+DROP TABLE IF EXISTS "merchantStaff";
+DROP TYPE IF EXISTS "merchantStaffRole";
+
+-- Postgres can't drop an enum value, super_admin is left in the role type
@@ -0,0 +1,21 @@
+-- super admin keeps global access to every merchant, it is granted manually in the database
+ALTER TYPE "role" ADD VALUE IF NOT EXISTS 'super_admin';
+
+CREATE TYPE "merchantStaffRole" AS ENUM (
+  'owner',
+  'manager'
+);
+
+CREATE TABLE IF NOT EXISTS "merchantStaff" (
+      "merchantId" UUID NOT NULL REFERENCES merchant(id),
+      "staffId" UUID NOT NULL REFERENCES "user"(id),
+      "role" "merchantStaffRole" NOT NULL,
+      "createdAt" TIMESTAMP NOT NULL,
+      PRIMARY KEY ("merchantId", "staffId")
+);
+
+-- Index on staffId to list the merchants managed by an admin
+CREATE INDEX IF NOT EXISTS idx_merchant_staff_staff_id ON "merchantStaff" ("staffId");
+
+-- Existing merchants have no recorded owner, so they are only reachable by super admin
+-- until a merchantStaff row is inserted for them.
@@ -0,0 +1,6 @@
+-- Drop the schedule tables
+DROP TABLE IF EXISTS "merchantScheduleOverride";
+DROP INDEX IF EXISTS idx_merchant_opening_hours_merchant_id;
+DROP TABLE IF EXISTS "merchantOpeningHours";
+
+ALTER TABLE "merchant" DROP COLUMN IF EXISTS "timezone";
@@ -0,0 +1,23 @@
+-- opening hours are evaluated in the merchant local time
+ALTER TABLE "merchant" ADD COLUMN IF NOT EXISTS "timezone" VARCHAR NOT NULL DEFAULT 'UTC';
+
+-- weekly schedule, closeTime before or equal to openTime means the span continues past midnight.
+-- merchant without any row is open all day.
+CREATE TABLE IF NOT EXISTS "merchantOpeningHours" (
+      "merchantId" UUID NOT NULL REFERENCES merchant(id),
+      "dayOfWeek" SMALLINT NOT NULL CHECK ("dayOfWeek" BETWEEN 0 AND 6), -- 0 is sunday
+      "openTime" TIME NOT NULL,
+      "closeTime" TIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_merchant_opening_hours_merchant_id ON "merchantOpeningHours" ("merchantId");
+
+-- holiday override replace the weekly schedule of a single date
+CREATE TABLE IF NOT EXISTS "merchantScheduleOverride" (
+      "merchantId" UUID NOT NULL REFERENCES merchant(id),
+      "date" DATE NOT NULL,
+      "isClosed" BOOLEAN NOT NULL DEFAULT FALSE,
+      "openTime" TIME, -- null when closed the whole day
+      "closeTime" TIME,
+      PRIMARY KEY ("merchantId", "date")
+);
@@ -0,0 +1,7 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_item_option_group_id;
+DROP INDEX IF EXISTS idx_item_option_group_item_id;
+
+-- Drop the option tables
+DROP TABLE IF EXISTS "itemOption";
+DROP TABLE IF EXISTS "itemOptionGroup";
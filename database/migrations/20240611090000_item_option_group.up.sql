@@ -0,0 +1,25 @@
+-- option group of an item, e.g. size or toppings, customer picks between minSelect and maxSelect options
+CREATE TABLE IF NOT EXISTS "itemOptionGroup" (
+      "id" UUID PRIMARY KEY,
+      "itemId" UUID NOT NULL REFERENCES "merchantItem"(id),
+      "name" VARCHAR NOT NULL,
+      "minSelect" INTEGER NOT NULL DEFAULT 0,
+      "maxSelect" INTEGER NOT NULL DEFAULT 1,
+      "sortOrder" INTEGER NOT NULL DEFAULT 0,
+      CHECK ("minSelect" >= 0 AND "maxSelect" >= "minSelect")
+);
+
+CREATE INDEX IF NOT EXISTS idx_item_option_group_item_id ON "itemOptionGroup" ("itemId");
+
+-- linkedItemId attach an Additions or Condiments item of the same merchant as an option
+CREATE TABLE IF NOT EXISTS "itemOption" (
+      "id" UUID PRIMARY KEY,
+      "groupId" UUID NOT NULL REFERENCES "itemOptionGroup"(id) ON DELETE CASCADE,
+      "name" VARCHAR NOT NULL,
+      "priceDelta" INTEGER NOT NULL DEFAULT 0,
+      "linkedItemId" UUID REFERENCES "merchantItem"(id),
+      "isAvailable" BOOLEAN NOT NULL DEFAULT TRUE,
+      "sortOrder" INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_item_option_group_id ON "itemOption" ("groupId");
@@ -0,0 +1,5 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_item_price_item_id_effective_from;
+
+-- Drop the itemPrice table
+DROP TABLE IF EXISTS "itemPrice";
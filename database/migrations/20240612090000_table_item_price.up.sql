@@ -0,0 +1,18 @@
+-- price history of an item, the version effective now with the latest effectiveFrom is the current price.
+-- merchantItem.price stays as the base price of items without any version.
+CREATE TABLE IF NOT EXISTS "itemPrice" (
+      "id" UUID PRIMARY KEY,
+      "itemId" UUID NOT NULL REFERENCES "merchantItem"(id),
+      "price" INTEGER NOT NULL,
+      "effectiveFrom" TIMESTAMP NOT NULL,
+      "effectiveTo" TIMESTAMP, -- null means until replaced
+      "createdBy" UUID,
+      "createdAt" TIMESTAMP NOT NULL,
+      CHECK ("effectiveTo" IS NULL OR "effectiveTo" > "effectiveFrom")
+);
+
+CREATE INDEX IF NOT EXISTS idx_item_price_item_id_effective_from ON "itemPrice" ("itemId", "effectiveFrom" DESC);
+
+-- existing price become the first version of every item
+INSERT INTO "itemPrice" ("id", "itemId", "price", "effectiveFrom", "createdAt")
+SELECT md5(id::text || 'itemPrice')::uuid, id, price, "createdAt", "createdAt" FROM "merchantItem";
@@ -0,0 +1,13 @@
+ALTER TABLE "calculatedEstimate"
+    DROP COLUMN IF EXISTS "subtotalPrice",
+    DROP COLUMN IF EXISTS "promoCodeId",
+    DROP COLUMN IF EXISTS "discountAmount",
+    DROP COLUMN IF EXISTS "discountBreakdown";
+
+-- Drop indexes
+DROP INDEX IF EXISTS idx_promo_code_usage_promo_code_id_user_id;
+
+-- Drop the promo tables
+DROP TABLE IF EXISTS "promoCodeUsage";
+DROP TABLE IF EXISTS "promoCode";
+DROP TYPE IF EXISTS "promoDiscountType";
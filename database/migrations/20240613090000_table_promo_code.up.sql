@@ -0,0 +1,48 @@
+CREATE TYPE "promoDiscountType" AS ENUM (
+  'percentage',
+  'fixed'
+);
+
+-- code is stored upper case, empty scope array means the promo apply to every merchant/category
+CREATE TABLE IF NOT EXISTS "promoCode" (
+      "id" UUID PRIMARY KEY,
+      "code" VARCHAR NOT NULL UNIQUE,
+      "discountType" "promoDiscountType" NOT NULL,
+      "discountValue" INTEGER NOT NULL,
+      "maxDiscount" INTEGER, -- cap of percentage discount, null means no cap
+      "minSpend" INTEGER NOT NULL DEFAULT 0,
+      "perUserLimit" INTEGER, -- null means unlimited
+      "usageLimit" INTEGER, -- null means unlimited
+      "usedCount" INTEGER NOT NULL DEFAULT 0,
+      "validFrom" TIMESTAMP NOT NULL,
+      "validTo" TIMESTAMP NOT NULL,
+      "merchantIds" UUID[] NOT NULL DEFAULT '{}',
+      "merchantCategories" VARCHAR[] NOT NULL DEFAULT '{}',
+      "itemCategories" VARCHAR[] NOT NULL DEFAULT '{}',
+      "createdBy" UUID,
+      "createdAt" TIMESTAMP NOT NULL,
+      CHECK ("validTo" > "validFrom"),
+      CHECK ("discountValue" > 0)
+);
+
+-- one row per confirmed order that used a promo
+CREATE TABLE IF NOT EXISTS "promoCodeUsage" (
+      "id" UUID PRIMARY KEY,
+      "promoCodeId" UUID NOT NULL REFERENCES "promoCode"(id),
+      "userId" UUID NOT NULL,
+      "orderId" UUID NOT NULL UNIQUE REFERENCES "order"("orderId"),
+      "calculatedEstimateId" UUID NOT NULL REFERENCES "calculatedEstimate"("calculatedEstimateId"),
+      "discount" INTEGER NOT NULL,
+      "createdAt" TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_promo_code_usage_promo_code_id_user_id ON "promoCodeUsage" ("promoCodeId", "userId");
+
+-- totalPrice become subtotalPrice - discountAmount
+ALTER TABLE "calculatedEstimate"
+    ADD COLUMN IF NOT EXISTS "subtotalPrice" INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS "promoCodeId" UUID REFERENCES "promoCode"(id),
+    ADD COLUMN IF NOT EXISTS "discountAmount" INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS "discountBreakdown" JSONB NOT NULL DEFAULT '[]';
+
+UPDATE "calculatedEstimate" SET "subtotalPrice" = "totalPrice";
@@ -0,0 +1,5 @@
+ALTER TABLE "calculatedEstimate"
+    DROP COLUMN IF EXISTS "deliveryFee",
+    DROP COLUMN IF EXISTS "extraMerchantFee",
+    DROP COLUMN IF EXISTS "smallOrderFee",
+    DROP COLUMN IF EXISTS "routeDistanceInKm";
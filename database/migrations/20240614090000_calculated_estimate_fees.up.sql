@@ -0,0 +1,7 @@
+-- fees of the estimate, totalPrice = subtotalPrice + fees - discountAmount.
+-- existing estimates were priced without fees so they stay 0
+ALTER TABLE "calculatedEstimate"
+    ADD COLUMN IF NOT EXISTS "deliveryFee" INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS "extraMerchantFee" INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS "smallOrderFee" INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS "routeDistanceInKm" DOUBLE PRECISION NOT NULL DEFAULT 0;
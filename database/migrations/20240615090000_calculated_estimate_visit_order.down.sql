@@ -0,0 +1,2 @@
+ALTER TABLE "calculatedEstimate"
+    DROP COLUMN IF EXISTS "visitOrder";
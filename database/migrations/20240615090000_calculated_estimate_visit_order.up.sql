@@ -0,0 +1,3 @@
+-- merchant ids in the order the courier visit them, the user is always the last stop
+ALTER TABLE "calculatedEstimate"
+    ADD COLUMN IF NOT EXISTS "visitOrder" UUID[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,9 @@
+ALTER TABLE "calculatedEstimate"
+    DROP COLUMN IF EXISTS "preparationTimeInMinutes",
+    DROP COLUMN IF EXISTS "travelTimeInMinutes";
+
+ALTER TABLE "merchantItem"
+    DROP COLUMN IF EXISTS "prepTimeMinutes";
+
+ALTER TABLE "merchant"
+    DROP COLUMN IF EXISTS "prepTimeMinutes";
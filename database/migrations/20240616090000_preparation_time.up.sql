@@ -0,0 +1,14 @@
+-- minutes to prepare an order, null on merchant means the default of its category
+-- and null on item means the item is ready within the merchant preparation time
+ALTER TABLE "merchant"
+    ADD COLUMN IF NOT EXISTS "prepTimeMinutes" INTEGER CHECK ("prepTimeMinutes" >= 0);
+
+ALTER TABLE "merchantItem"
+    ADD COLUMN IF NOT EXISTS "prepTimeMinutes" INTEGER CHECK ("prepTimeMinutes" >= 0);
+
+-- the estimated delivery time is the courier waiting for the food plus travelling
+ALTER TABLE "calculatedEstimate"
+    ADD COLUMN IF NOT EXISTS "preparationTimeInMinutes" INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS "travelTimeInMinutes" INTEGER NOT NULL DEFAULT 0;
+
+UPDATE "calculatedEstimate" SET "travelTimeInMinutes" = "estimatedDeliveryTimeInMinutes";
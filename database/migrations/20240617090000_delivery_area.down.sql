@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "serviceArea";
+
+ALTER TABLE "merchant"
+    DROP COLUMN IF EXISTS "deliveryRadiusKm";
@@ -0,0 +1,11 @@
+-- km from the merchant it delivers to, null means the default delivery radius
+ALTER TABLE "merchant"
+    ADD COLUMN IF NOT EXISTS "deliveryRadiusKm" DOUBLE PRECISION CHECK ("deliveryRadiusKm" > 0);
+
+-- city level area the delivery operates in, geometry is a GeoJSON Polygon or MultiPolygon
+CREATE TABLE IF NOT EXISTS "serviceArea" (
+      "id" UUID PRIMARY KEY,
+      "name" VARCHAR NOT NULL UNIQUE,
+      "geometry" JSONB NOT NULL,
+      "createdAt" TIMESTAMP NOT NULL
+);
@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"beli-mang/model"
+	"beli-mang/pkg/customErr"
+	"beli-mang/repo"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// Idempotency replays the stored response when a request is retried with the same Idempotency-Key header.
+// It must be placed after Authentication because the key is scoped per user.
+// The key is held for lease while the request runs and the response is replayed for ttl.
+func Idempotency(store repo.IdempotencyRepository, ttl, lease time.Duration, logger *zap.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get(model.IdempotencyKeyHeader)
+			if key == "" {
+				return next(c)
+			}
+
+			payload, ok := c.Get("userData").(*model.JWTPayload)
+			if !ok {
+				resErr := customErr.NewUnauthorizedError("Unauthorized")
+				return c.JSON(resErr.StatusCode, resErr)
+			}
+			userId, _ := uuid.Parse(payload.Id)
+
+			body, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				resErr := customErr.NewBadRequestError("invalid request body")
+				return c.JSON(resErr.StatusCode, resErr)
+			}
+			// put back the body so the handler can still bind it
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := c.Request().Context()
+			now := time.Now()
+			record := model.IdempotencyRecord{
+				Key:         key,
+				UserId:      userId,
+				RequestHash: hashRequest(c.Request().Method, c.Request().URL.Path, body),
+				CreatedAt:   now,
+				ExpiresAt:   now.Add(lease),
+			}
+			reserved, err := store.Reserve(ctx, record)
+			if err != nil {
+				return err
+			}
+			if !reserved {
+				return replayResponse(c, store, record)
+			}
+
+			// capture the response written by the handler
+			writer := &bodyCaptureWriter{ResponseWriter: c.Response().Writer, body: &bytes.Buffer{}}
+			c.Response().Writer = writer
+
+			err = next(c)
+			// the client may be gone, the key must still be released or saved
+			storeCtx := context.WithoutCancel(ctx)
+			status := c.Response().Status
+			if (err != nil && !c.Response().Committed) || status >= http.StatusInternalServerError {
+				// server failure is not stored, so the client can retry with the same key
+				if errDelete := store.Delete(storeCtx, userId, key); errDelete != nil {
+					logger.Error("[idempotency] failed to release key", zap.String("key", key), zap.Error(errDelete))
+				}
+				return err
+			}
+
+			record.StatusCode = &status
+			record.ContentType = c.Response().Header().Get(echo.HeaderContentType)
+			record.ResponseBody = writer.body.Bytes()
+			record.ExpiresAt = time.Now().Add(ttl)
+			if errSave := store.SaveResponse(storeCtx, record); errSave != nil {
+				logger.Error("[idempotency] failed to save response", zap.String("key", key), zap.Error(errSave))
+			}
+			return err
+		}
+	}
+}
+
+func replayResponse(c echo.Context, store repo.IdempotencyRepository, record model.IdempotencyRecord) error {
+	stored, err := store.Get(c.Request().Context(), record.UserId, record.Key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// the key is released by a failed request in the meantime
+			resErr := customErr.NewConflictError("request with the same Idempotency-Key failed, please retry")
+			return c.JSON(resErr.StatusCode, resErr)
+		}
+		return err
+	}
+
+	if stored.RequestHash != record.RequestHash {
+		resErr := customErr.New(http.StatusUnprocessableEntity, "Idempotency-Key already used with a different request")
+		return c.JSON(resErr.StatusCode, resErr)
+	}
+	if stored.StatusCode == nil {
+		resErr := customErr.NewConflictError("request with the same Idempotency-Key is still in progress")
+		return c.JSON(resErr.StatusCode, resErr)
+	}
+
+	c.Response().Header().Set("Idempotent-Replayed", "true")
+	return c.Blob(*stored.StatusCode, stored.ContentType, stored.ResponseBody)
+}
+
+func hashRequest(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte(path))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type bodyCaptureWriter struct {
+	http.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *bodyCaptureWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"beli-mang/model"
+	"beli-mang/pkg/customErr"
+	"beli-mang/repo"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// MerchantAccess only let the request through when the caller manages the merchant in the :merchantId path param,
+// super admin can access every merchant. It must be placed after Authentication.
+func MerchantAccess(merchantRepo repo.MerchantRepository) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			payload, ok := c.Get("userData").(*model.JWTPayload)
+			if !ok {
+				resErr := customErr.NewUnauthorizedError("Unauthorized")
+				return c.JSON(resErr.StatusCode, resErr)
+			}
+			if payload.Role == model.RoleSuperAdmin {
+				return next(c)
+			}
+
+			// merchant of another admin is treated as not found
+			merchantId, err := uuid.Parse(c.Param("merchantId"))
+			if err != nil {
+				resErr := customErr.NewNotFoundError("merchant not found")
+				return c.JSON(resErr.StatusCode, resErr)
+			}
+			staffId, _ := uuid.Parse(payload.Id)
+
+			allowed, err := merchantRepo.IsMerchantStaff(c.Request().Context(), staffId, []uuid.UUID{merchantId})
+			if err != nil {
+				return err
+			}
+			if !allowed {
+				resErr := customErr.NewNotFoundError("merchant not found")
+				return c.JSON(resErr.StatusCode, resErr)
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -33,7 +33,8 @@ func Authentication(secret string, role model.Role) echo.MiddlewareFunc {
 			// Add user data to the request context
 			c.Set("userData", payload)
 
-			if payload.Role != role && role != model.RoleAll {
+			// super admin pass every admin check
+			if payload.Role != role && role != model.RoleAll && !(role == model.RoleAdmin && payload.Role.IsAdmin()) {
 				resErr := customErr.NewUnauthorizedError("Unauthorized, invalid Role")
 				return c.JSON(resErr.StatusCode, resErr)
 			}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CatalogExportFormat string
+
+const (
+	CatalogExportNDJSON CatalogExportFormat = "ndjson"
+	CatalogExportCSV    CatalogExportFormat = "csv"
+)
+
+// CatalogExportParams filter the exported catalog, the createdAt range and the item category apply to the items.
+// Merchant without item is only exported when no item filter is set.
+type CatalogExportParams struct {
+	Format           CatalogExportFormat
+	MerchantId       *uuid.UUID
+	MerchantCategory string
+	ItemCategory     string
+	CreatedAtFrom    *time.Time
+	CreatedAtTo      *time.Time
+	// StaffId limit the export to the merchants managed by the staff, empty means every merchant
+	StaffId string
+}
+
+// CatalogRow is a merchant with one of its items, Item is nil for merchant without item
+type CatalogRow struct {
+	Merchant Merchant      `json:"merchant"`
+	Item     *MerchantItem `json:"item"`
+}
+
+// CatalogExportError is the last NDJSON line of an export that failed after streaming started
+type CatalogExportError struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotencyRecord is the stored response of a request sent with Idempotency-Key header
+type IdempotencyRecord struct {
+	Key          string    `db:"key"`
+	UserId       uuid.UUID `db:"userId"`
+	RequestHash  string    `db:"requestHash"`
+	StatusCode   *int      `db:"statusCode"`
+	ContentType  string    `db:"contentType"`
+	ResponseBody []byte    `db:"responseBody"`
+	CreatedAt    time.Time `db:"createdAt"`
+	// ExpiresAt is the end of the lease while the request is in progress, then the end of the replay
+	ExpiresAt time.Time `db:"expiresAt"`
+}
@@ -7,14 +7,27 @@ import (
 
 type ItemCategory string
 
+// item category that can be attached to another item as an option
+const (
+	ItemCategoryAdditions  ItemCategory = "Additions"
+	ItemCategoryCondiments ItemCategory = "Condiments"
+)
+
 type Item struct {
-	Id         uuid.UUID    `json:"id" db:"id"`
-	MerchantId uuid.UUID    `json:"merchantId" db:"merchantId"`
-	Name       string       `json:"name" db:"name"`
-	Category   ItemCategory `json:"category" db:"category"`
-	ImageUrl   string       `json:"imageUrl" db:"imageUrl"`
-	Price      int          `json:"price" db:"price"`
-	CreatedAt  time.Time    `json:"createdAt" db:"createdAt"`
+	Id          uuid.UUID    `json:"id" db:"id"`
+	MerchantId  uuid.UUID    `json:"merchantId" db:"merchantId"`
+	Name        string       `json:"name" db:"name"`
+	Category    ItemCategory `json:"category" db:"category"`
+	ImageUrl    string       `json:"imageUrl" db:"imageUrl"`
+	Price       int          `json:"price" db:"price"`
+	IsAvailable bool         `json:"isAvailable" db:"isAvailable"`
+	CreatedAt   time.Time    `json:"createdAt" db:"createdAt"`
+	// PriceId is the price version of Price, nil when the item has no price history
+	PriceId *uuid.UUID `json:"priceId,omitempty" db:"priceId"`
+	// PrepTimeMinutes nil means the item is ready within the merchant preparation time
+	PrepTimeMinutes *int `json:"prepTimeMinutes,omitempty" db:"prepTimeMinutes"`
+	// OptionGroups is only loaded for the nearby listing
+	OptionGroups []ItemOptionGroup `json:"optionGroups,omitempty" db:"-"`
 }
 
 func (i Item) ItemToBoughtItem() BoughtItem {
@@ -23,6 +36,8 @@ func (i Item) ItemToBoughtItem() BoughtItem {
 		Name:            i.Name,
 		ProductCategory: string(i.Category),
 		Price:           i.Price,
+		PriceId:         i.PriceId,
+		PrepTimeMinutes: i.PrepTimeMinutes,
 		ImageUrl:        i.ImageUrl,
 		CreatedAt:       i.CreatedAt,
 		Quantity:        0,
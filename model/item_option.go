@@ -0,0 +1,55 @@
+package model
+
+import (
+	"github.com/google/uuid"
+)
+
+// ItemOptionGroup is a choice on an item, e.g. size or toppings.
+// Customer must pick between MinSelect and MaxSelect options of the group.
+type ItemOptionGroup struct {
+	ID        uuid.UUID    `json:"optionGroupId" db:"id"`
+	ItemId    uuid.UUID    `json:"itemId" db:"itemId"`
+	Name      string       `json:"name" db:"name"`
+	MinSelect int          `json:"minSelect" db:"minSelect"`
+	MaxSelect int          `json:"maxSelect" db:"maxSelect"`
+	Options   []ItemOption `json:"options" db:"-"`
+}
+
+// ItemOption is added to the item price with PriceDelta,
+// LinkedItemId points to the Additions or Condiments item it represents.
+type ItemOption struct {
+	ID           uuid.UUID  `json:"optionId" db:"id"`
+	GroupId      uuid.UUID  `json:"-" db:"groupId"`
+	Name         string     `json:"name" db:"name"`
+	PriceDelta   int        `json:"priceDelta" db:"priceDelta"`
+	LinkedItemId *uuid.UUID `json:"linkedItemId,omitempty" db:"linkedItemId"`
+	IsAvailable  bool       `json:"isAvailable" db:"isAvailable"`
+}
+
+// SetItemOptionGroupsRequest replace every option group of the item
+type SetItemOptionGroupsRequest struct {
+	OptionGroups []ItemOptionGroupRequest `json:"optionGroups" validate:"dive"`
+}
+
+type ItemOptionGroupRequest struct {
+	Name      string              `json:"name" validate:"required,min=1,max=30"`
+	MinSelect int                 `json:"minSelect" validate:"min=0"`
+	MaxSelect int                 `json:"maxSelect" validate:"min=1,gtefield=MinSelect"`
+	Options   []ItemOptionRequest `json:"options" validate:"required,min=1,dive"`
+}
+
+type ItemOptionRequest struct {
+	Name         string  `json:"name" validate:"required,min=1,max=30"`
+	PriceDelta   int     `json:"priceDelta" validate:"min=0"`
+	LinkedItemId *string `json:"linkedItemId" validate:"omitempty,uuid"`
+	// IsAvailable default to true when not sent
+	IsAvailable *bool `json:"isAvailable"`
+}
+
+// BoughtItemOption is the snapshot of a selected option stored in the order detail
+type BoughtItemOption struct {
+	OptionId   uuid.UUID `json:"optionId"`
+	GroupName  string    `json:"groupName"`
+	Name       string    `json:"name"`
+	PriceDelta int       `json:"priceDelta"`
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ItemPrice is a version of the item price, EffectiveTo nil means until replaced by a later version
+type ItemPrice struct {
+	ID            uuid.UUID  `json:"priceId" db:"id"`
+	ItemId        uuid.UUID  `json:"itemId" db:"itemId"`
+	Price         int        `json:"price" db:"price"`
+	EffectiveFrom time.Time  `json:"effectiveFrom" db:"effectiveFrom"`
+	EffectiveTo   *time.Time `json:"effectiveTo" db:"effectiveTo"`
+	CreatedBy     *uuid.UUID `json:"createdBy,omitempty" db:"createdBy"`
+	CreatedAt     time.Time  `json:"createdAt" db:"createdAt"`
+	// IsCurrent mark the version used for the price right now
+	IsCurrent bool `json:"isCurrent" db:"-"`
+}
+
+// IsEffectiveAt reports whether the version is in effect at t
+func (p ItemPrice) IsEffectiveAt(t time.Time) bool {
+	return !p.EffectiveFrom.After(t) && (p.EffectiveTo == nil || p.EffectiveTo.After(t))
+}
+
+// ScheduleItemPriceRequest add a price version, EffectiveFrom default to now
+type ScheduleItemPriceRequest struct {
+	Price         int        `json:"price" validate:"required,min=1"`
+	EffectiveFrom *time.Time `json:"effectiveFrom"`
+	EffectiveTo   *time.Time `json:"effectiveTo"`
+}
@@ -18,6 +18,16 @@ const (
 	ConvenienceStore      MerchantCategory = "ConvenienceStore"
 )
 
+// DefaultPrepTimeMinutes is the preparation time of a merchant that doesn't set its own
+var DefaultPrepTimeMinutes = map[MerchantCategory]int{
+	SmallRestaurant:       10,
+	MediumRestaurant:      15,
+	LargeRestaurant:       25,
+	MerchandiseRestaurant: 5,
+	BoothKiosk:            5,
+	ConvenienceStore:      3,
+}
+
 type Merchant struct {
 	ID        uuid.UUID        `json:"merchantId" db:"id"`
 	Name      string           `json:"name" db:"name"`
@@ -25,6 +35,42 @@ type Merchant struct {
 	ImageURL  string           `json:"imageUrl" db:"imageUrl"`
 	Location  Location         `json:"location" db:"-"`
 	CreatedAt time.Time        `json:"createdAt" db:"createdAt"`
+	// PrepTimeMinutes nil means DefaultPrepTimeMinutes of the category
+	PrepTimeMinutes *int `json:"prepTimeMinutes" db:"prepTimeMinutes"`
+	// DeliveryRadiusKm nil means the default delivery radius
+	DeliveryRadiusKm *float64 `json:"deliveryRadiusKm" db:"deliveryRadiusKm"`
+}
+
+// PrepTime is how long the merchant needs to prepare an order
+func (m Merchant) PrepTime() time.Duration {
+	minutes := DefaultPrepTimeMinutes[m.Category]
+	if m.PrepTimeMinutes != nil {
+		minutes = *m.PrepTimeMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// DeliveryRadius is how far in km the merchant deliver, defaultKm is used when it doesn't set its own
+func (m Merchant) DeliveryRadius(defaultKm float64) float64 {
+	if m.DeliveryRadiusKm != nil {
+		return *m.DeliveryRadiusKm
+	}
+	return defaultKm
+}
+
+type MerchantStaffRole string
+
+const (
+	MerchantStaffOwner   MerchantStaffRole = "owner"
+	MerchantStaffManager MerchantStaffRole = "manager"
+)
+
+// MerchantStaff record which admin manages which merchant
+type MerchantStaff struct {
+	MerchantId uuid.UUID         `json:"merchantId" db:"merchantId"`
+	StaffId    uuid.UUID         `json:"staffId" db:"staffId"`
+	Role       MerchantStaffRole `json:"role" db:"role"`
+	CreatedAt  time.Time         `json:"createdAt" db:"createdAt"`
 }
 
 type CreateMerchantRequest struct {
@@ -32,6 +78,20 @@ type CreateMerchantRequest struct {
 	Category string   `json:"merchantCategory" validate:"required,oneof=SmallRestaurant MediumRestaurant LargeRestaurant MerchandiseRestaurant BoothKiosk ConvenienceStore"`
 	ImageURL string   `json:"imageUrl" validate:"required,custom_url"`
 	Location Location `json:"location" validate:"required"`
+	// PrepTimeMinutes is optional, the default of the category is used when empty
+	PrepTimeMinutes *int `json:"prepTimeMinutes" validate:"omitempty,min=0,max=180"`
+	// DeliveryRadiusKm is optional, the default delivery radius is used when empty
+	DeliveryRadiusKm *float64 `json:"deliveryRadiusKm" validate:"omitempty,gt=0,max=50"`
+}
+
+// UpdateMerchantRequest only update the field that is sent
+type UpdateMerchantRequest struct {
+	Name             *string   `json:"name" validate:"omitempty,min=2,max=30"`
+	Category         *string   `json:"merchantCategory" validate:"omitempty,oneof=SmallRestaurant MediumRestaurant LargeRestaurant MerchandiseRestaurant BoothKiosk ConvenienceStore"`
+	ImageURL         *string   `json:"imageUrl" validate:"omitempty,custom_url"`
+	Location         *Location `json:"location" validate:"omitempty"`
+	PrepTimeMinutes  *int      `json:"prepTimeMinutes" validate:"omitempty,min=0,max=180"`
+	DeliveryRadiusKm *float64  `json:"deliveryRadiusKm" validate:"omitempty,gt=0,max=50"`
 }
 
 type Location struct {
@@ -66,16 +126,30 @@ type GetMerchantParams struct {
 	Limit            int
 	Offset           int
 	CreatedAt        string
+	// StaffId limit the result to the merchants managed by the staff, empty means every merchant
+	StaffId string
+	// AvailableOnly hide the sold out items in nearby listing
+	AvailableOnly bool
+	// DefaultDeliveryRadiusKm limit nearby listing to the merchants delivering to the location,
+	// it is the radius of the merchants without their own
+	DefaultDeliveryRadiusKm float64
+	// ServiceArea limit nearby listing to the merchants inside it, nil means no limit
+	ServiceArea MultiPolygon
 }
 
 type MerchantItem struct {
-	ID         uuid.UUID `json:"itemId" db:"id"`
-	MerchantId uuid.UUID `json:"merchantId" db:"merchantId"`
-	Name       string    `json:"name" db:"name"`
-	Category   string    `json:"productCategory" db:"category"`
-	ImageURL   string    `json:"imageUrl" db:"imageUrl"`
-	Price      int       `json:"price" db:"price"`
-	CreatedAt  time.Time `json:"createdAt" db:"createdAt"`
+	ID          uuid.UUID `json:"itemId" db:"id"`
+	MerchantId  uuid.UUID `json:"merchantId" db:"merchantId"`
+	Name        string    `json:"name" db:"name"`
+	Category    string    `json:"productCategory" db:"category"`
+	ImageURL    string    `json:"imageUrl" db:"imageUrl"`
+	Price       int       `json:"price" db:"price"`
+	IsAvailable bool      `json:"isAvailable" db:"isAvailable"`
+	CreatedAt   time.Time `json:"createdAt" db:"createdAt"`
+	// PriceId is the price version of Price, nil when the item has no price history
+	PriceId *uuid.UUID `json:"priceId,omitempty" db:"priceId"`
+	// PrepTimeMinutes nil means the item is ready within the merchant preparation time
+	PrepTimeMinutes *int `json:"prepTimeMinutes" db:"prepTimeMinutes"`
 }
 
 type CreateMerchantItemRequest struct {
@@ -83,12 +157,42 @@ type CreateMerchantItemRequest struct {
 	ProductCategory string `json:"productCategory" validate:"required,oneof=Beverage Food Snack Condiments Additions"`
 	ImageURL        string `json:"imageUrl" validate:"required,custom_url"`
 	Price           int    `json:"price" validate:"required"`
+	PrepTimeMinutes *int   `json:"prepTimeMinutes" validate:"omitempty,min=0,max=180"`
+}
+
+// UpdateMerchantItemRequest only update the field that is sent
+type UpdateMerchantItemRequest struct {
+	Name            *string `json:"name" validate:"omitempty,min=2,max=30"`
+	ProductCategory *string `json:"productCategory" validate:"omitempty,oneof=Beverage Food Snack Condiments Additions"`
+	ImageURL        *string `json:"imageUrl" validate:"omitempty,custom_url"`
+	Price           *int    `json:"price" validate:"omitempty,min=1"`
+	IsAvailable     *bool   `json:"isAvailable"`
+	PrepTimeMinutes *int    `json:"prepTimeMinutes" validate:"omitempty,min=0,max=180"`
 }
 
 type CreateMerchantItemResponse struct {
 	ItemId string `json:"itemId"`
 }
 
+// MaxImportItemRows limit the number of rows of a single bulk import
+const MaxImportItemRows = 5000
+
+// ImportItemRowError report why a row of the bulk import is rejected, row starts from 1 for the first data row
+type ImportItemRowError struct {
+	Row   int    `json:"row"`
+	Field string `json:"field,omitempty"`
+	Error string `json:"error"`
+}
+
+type ImportMerchantItemsResponse struct {
+	DryRun       bool                 `json:"dryRun"`
+	TotalRows    int                  `json:"totalRows"`
+	ValidRows    int                  `json:"validRows"`
+	InsertedRows int                  `json:"insertedRows"`
+	ItemIds      []string             `json:"itemIds,omitempty"`
+	Errors       []ImportItemRowError `json:"errors"`
+}
+
 type GetMerchantItemParams struct {
 	MerchantId      string
 	ItemId          string
@@ -103,4 +207,5 @@ type GetNearbyMerchantData struct {
 	Merchant Merchant `json:"merchant"`
 	Items    []Item   `json:"items"`
 	Distance string   `json:"distance"`
+	IsOpen   bool     `json:"isOpen"`
 }
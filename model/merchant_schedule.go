@@ -0,0 +1,125 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// ScheduleTimeLayout is the layout of the opening and closing time, e.g. 21:30
+	ScheduleTimeLayout = "15:04"
+	// ScheduleDateLayout is the layout of the holiday override date
+	ScheduleDateLayout = "2006-01-02"
+
+	minutesPerDay = 24 * 60
+)
+
+// OpeningHour is a weekly opening span in the merchant local time.
+// CloseTime before or equal to OpenTime means the merchant closes on the next day.
+type OpeningHour struct {
+	DayOfWeek time.Weekday `json:"dayOfWeek" db:"dayOfWeek" validate:"min=0,max=6"`
+	OpenTime  string       `json:"openTime" db:"openTime" validate:"required,datetime=15:04"`
+	CloseTime string       `json:"closeTime" db:"closeTime" validate:"required,datetime=15:04"`
+}
+
+// ScheduleOverride replace the weekly opening hours of a single date, e.g. a public holiday
+type ScheduleOverride struct {
+	Date      string  `json:"date" db:"date" validate:"required,datetime=2006-01-02"`
+	IsClosed  bool    `json:"isClosed" db:"isClosed"`
+	OpenTime  *string `json:"openTime,omitempty" db:"openTime" validate:"required_without=IsClosed,omitempty,datetime=15:04"`
+	CloseTime *string `json:"closeTime,omitempty" db:"closeTime" validate:"required_without=IsClosed,omitempty,datetime=15:04"`
+}
+
+type MerchantSchedule struct {
+	MerchantId   uuid.UUID          `json:"merchantId"`
+	Timezone     string             `json:"timezone" validate:"required,timezone"`
+	OpeningHours []OpeningHour      `json:"openingHours" validate:"dive"`
+	Overrides    []ScheduleOverride `json:"overrides" validate:"dive"`
+}
+
+// ClosedMerchant is reported when an order contains a merchant outside its opening hours
+type ClosedMerchant struct {
+	MerchantId uuid.UUID `json:"merchantId"`
+	Name       string    `json:"name"`
+}
+
+type scheduleSpan struct {
+	open, close int // minute of the day
+}
+
+func (s scheduleSpan) overnight() bool {
+	return s.close <= s.open
+}
+
+// IsOpenAt reports whether the merchant is open at t.
+// Merchant without weekly opening hours is open all day, except on the closed override dates.
+func (s MerchantSchedule) IsOpenAt(t time.Time) bool {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := t.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+
+	for _, span := range s.spansOn(local) {
+		if minute >= span.open && (minute < span.close || span.overnight()) {
+			return true
+		}
+	}
+	// overnight span of the previous day
+	for _, span := range s.spansOn(local.AddDate(0, 0, -1)) {
+		if span.overnight() && minute < span.close {
+			return true
+		}
+	}
+	return false
+}
+
+// spansOn returns the opening spans starting on the date of day
+func (s MerchantSchedule) spansOn(day time.Time) []scheduleSpan {
+	date := day.Format(ScheduleDateLayout)
+	for _, o := range s.Overrides {
+		if o.Date != date {
+			continue
+		}
+		if o.IsClosed || o.OpenTime == nil || o.CloseTime == nil {
+			return nil
+		}
+		span, ok := parseScheduleSpan(*o.OpenTime, *o.CloseTime)
+		if !ok {
+			return nil
+		}
+		return []scheduleSpan{span}
+	}
+
+	if len(s.OpeningHours) == 0 {
+		return []scheduleSpan{{open: 0, close: minutesPerDay}}
+	}
+
+	var spans []scheduleSpan
+	for _, h := range s.OpeningHours {
+		if h.DayOfWeek != day.Weekday() {
+			continue
+		}
+		if span, ok := parseScheduleSpan(h.OpenTime, h.CloseTime); ok {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+func parseScheduleSpan(openTime, closeTime string) (scheduleSpan, bool) {
+	open, err := time.Parse(ScheduleTimeLayout, openTime)
+	if err != nil {
+		return scheduleSpan{}, false
+	}
+	closing, err := time.Parse(ScheduleTimeLayout, closeTime)
+	if err != nil {
+		return scheduleSpan{}, false
+	}
+	return scheduleSpan{
+		open:  open.Hour()*60 + open.Minute(),
+		close: closing.Hour()*60 + closing.Minute(),
+	}, true
+}
@@ -0,0 +1,123 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMerchantScheduleIsOpenAt(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2024-06-03 is a monday, 2024-06-07 a friday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, time.June, day, hour, minute, 0, 0, jakarta)
+	}
+	clock := func(s string) *string { return &s }
+
+	weekly := []OpeningHour{
+		{DayOfWeek: time.Monday, OpenTime: "09:00", CloseTime: "17:00"},
+		{DayOfWeek: time.Friday, OpenTime: "20:00", CloseTime: "02:00"},
+		{DayOfWeek: time.Sunday, OpenTime: "00:00", CloseTime: "00:00"},
+	}
+
+	tests := []struct {
+		name      string
+		timezone  string
+		hours     []OpeningHour
+		overrides []ScheduleOverride
+		t         time.Time
+		want      bool
+	}{
+		{name: "before opening", hours: weekly, t: at(3, 8, 59), want: false},
+		{name: "at opening", hours: weekly, t: at(3, 9, 0), want: true},
+		{name: "before closing", hours: weekly, t: at(3, 16, 59), want: true},
+		{name: "at closing", hours: weekly, t: at(3, 17, 0), want: false},
+		{name: "day without hours", hours: weekly, t: at(4, 10, 0), want: false},
+
+		{name: "overnight before midnight", hours: weekly, t: at(7, 23, 59), want: true},
+		{name: "overnight at midnight", hours: weekly, t: at(8, 0, 0), want: true},
+		{name: "overnight before closing", hours: weekly, t: at(8, 1, 59), want: true},
+		{name: "overnight at closing", hours: weekly, t: at(8, 2, 0), want: false},
+		{name: "overnight before opening", hours: weekly, t: at(7, 19, 59), want: false},
+		{name: "overnight doesn't open the morning before", hours: weekly, t: at(7, 1, 0), want: false},
+
+		{name: "same open and close is all day", hours: weekly, t: at(9, 23, 59), want: true},
+		{name: "all day ends at midnight", hours: weekly, t: at(10, 0, 0), want: false},
+
+		{
+			name:      "closed override",
+			hours:     weekly,
+			overrides: []ScheduleOverride{{Date: "2024-06-03", IsClosed: true}},
+			t:         at(3, 10, 0),
+			want:      false,
+		},
+		{
+			name:      "override hours replace weekly hours",
+			hours:     weekly,
+			overrides: []ScheduleOverride{{Date: "2024-06-03", OpenTime: clock("12:00"), CloseTime: clock("14:00")}},
+			t:         at(3, 10, 0),
+			want:      false,
+		},
+		{
+			name:      "inside override hours",
+			hours:     weekly,
+			overrides: []ScheduleOverride{{Date: "2024-06-03", OpenTime: clock("12:00"), CloseTime: clock("14:00")}},
+			t:         at(3, 13, 0),
+			want:      true,
+		},
+		{
+			name:      "override open on a day without hours",
+			hours:     weekly,
+			overrides: []ScheduleOverride{{Date: "2024-06-04", OpenTime: clock("08:00"), CloseTime: clock("12:00")}},
+			t:         at(4, 9, 0),
+			want:      true,
+		},
+		{
+			name:      "closed override on the day the overnight span starts",
+			hours:     weekly,
+			overrides: []ScheduleOverride{{Date: "2024-06-07", IsClosed: true}},
+			t:         at(8, 1, 0),
+			want:      false,
+		},
+		{
+			name:      "closed override of the next day keeps the overnight span",
+			hours:     weekly,
+			overrides: []ScheduleOverride{{Date: "2024-06-08", IsClosed: true}},
+			t:         at(8, 1, 0),
+			want:      true,
+		},
+		{
+			name:      "overnight override spill to the next day",
+			hours:     weekly,
+			overrides: []ScheduleOverride{{Date: "2024-06-04", OpenTime: clock("22:00"), CloseTime: clock("03:00")}},
+			t:         at(5, 2, 30),
+			want:      true,
+		},
+
+		{name: "no hours is open all day", t: at(4, 3, 0), want: true},
+		{
+			name:      "no hours but closed override",
+			overrides: []ScheduleOverride{{Date: "2024-06-04", IsClosed: true}},
+			t:         at(4, 3, 0),
+			want:      false,
+		},
+
+		{name: "utc time in the merchant timezone", hours: weekly, t: time.Date(2024, time.June, 3, 2, 0, 0, 0, time.UTC), want: true},
+		{name: "utc time before opening in the merchant timezone", hours: weekly, t: time.Date(2024, time.June, 3, 1, 59, 0, 0, time.UTC), want: false},
+		{name: "utc day differ from the merchant day", hours: weekly, t: time.Date(2024, time.June, 7, 18, 30, 0, 0, time.UTC), want: true},
+		{name: "invalid timezone fall back to utc", timezone: "Mars/Olympus", hours: weekly, t: time.Date(2024, time.June, 3, 9, 30, 0, 0, time.UTC), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := MerchantSchedule{Timezone: "Asia/Jakarta", OpeningHours: tt.hours, Overrides: tt.overrides}
+			if tt.timezone != "" {
+				schedule.Timezone = tt.timezone
+			}
+			if got := schedule.IsOpenAt(tt.t); got != tt.want {
+				t.Errorf("IsOpenAt(%s) = %v, want %v", tt.t, got, tt.want)
+			}
+		})
+	}
+}
@@ -16,6 +16,8 @@ type OrderRequest struct {
 type OrderRequestItem struct {
 	ItemId   string `json:"itemId" validate:"required"`
 	Quantity int    `json:"quantity"`
+	// Options is the selected option ids of the item option groups
+	Options []string `json:"options,omitempty" validate:"dive,uuid"`
 }
 
 type GetUserOrdersResponse []UserOrderData
@@ -29,6 +31,32 @@ type OrderData struct {
 	Merchant        Merchant     `json:"merchant"`
 	IsStartingPoint bool         `json:"isStartingPoint,omitempty"`
 	Items           []BoughtItem `json:"items"`
+	// Status is the merchant decision for this leg of the order, empty means not decided yet
+	Status OrderStatus `json:"status,omitempty"`
+}
+
+// PrepTime is how long the merchant needs to prepare the leg, items are prepared at the same time
+// so it is the slowest of the merchant and its items
+func (d OrderData) PrepTime() time.Duration {
+	prepTime := d.Merchant.PrepTime()
+	for _, item := range d.Items {
+		if item.PrepTimeMinutes == nil {
+			continue
+		}
+		if itemPrepTime := time.Duration(*item.PrepTimeMinutes) * time.Minute; itemPrepTime > prepTime {
+			prepTime = itemPrepTime
+		}
+	}
+	return prepTime
+}
+
+// TotalPrice sum price of every bought item in this leg
+func (d OrderData) TotalPrice() int {
+	total := 0
+	for _, item := range d.Items {
+		total += item.UnitPrice() * item.Quantity
+	}
+	return total
 }
 
 // Item ...
@@ -41,16 +69,85 @@ type BoughtItem struct {
 	ImageUrl        string    `json:"imageUrl"`
 	CreatedAt       time.Time `json:"createdAt"`
 	Quantity        int       `json:"quantity"`
+	// PriceId is the price version used by the estimate
+	PriceId *uuid.UUID `json:"priceId,omitempty"`
+	// Options is the snapshot of the selected options, Price doesn't include their price delta
+	Options []BoughtItemOption `json:"options,omitempty"`
+	// PrepTimeMinutes is the snapshot of the item preparation time, nil means the merchant one
+	PrepTimeMinutes *int `json:"prepTimeMinutes,omitempty"`
+}
+
+// UnitPrice is the item price plus the price delta of the selected options
+func (b BoughtItem) UnitPrice() int {
+	price := b.Price
+	for _, option := range b.Options {
+		price += option.PriceDelta
+	}
+	return price
+}
+
+// OptionIds returns the id of the selected options
+func (b BoughtItem) OptionIds() []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(b.Options))
+	for _, option := range b.Options {
+		ids = append(ids, option.OptionId)
+	}
+	return ids
 }
 
 // OrderStatus enum type
 type OrderStatus string
 
 const (
-	OrderStatusDraft   OrderStatus = "DRAFT"
-	OrderStatusCreated OrderStatus = "CREATED"
+	OrderStatusDraft     OrderStatus = "DRAFT"
+	OrderStatusCreated   OrderStatus = "CREATED"
+	OrderStatusAccepted  OrderStatus = "ACCEPTED"
+	OrderStatusPreparing OrderStatus = "PREPARING"
+	OrderStatusPickedUp  OrderStatus = "PICKED_UP"
+	OrderStatusDelivered OrderStatus = "DELIVERED"
+	OrderStatusCancelled OrderStatus = "CANCELLED"
+	OrderStatusRejected  OrderStatus = "REJECTED"
 )
 
+// OrderStatusHistory records every status transition of an order
+type OrderStatusHistory struct {
+	ID         uuid.UUID    `json:"id" db:"id"`
+	OrderID    uuid.UUID    `json:"orderId" db:"orderId"`
+	FromStatus *OrderStatus `json:"fromStatus" db:"fromStatus"`
+	ToStatus   OrderStatus  `json:"toStatus" db:"toStatus"`
+	ChangedBy  uuid.UUID    `json:"changedBy" db:"changedBy"`
+	CreatedAt  time.Time    `json:"createdAt" db:"createdAt"`
+	Seq        int64        `json:"seq" db:"seq"`
+}
+
+// OrderStatusEvent is the payload notified by postgres on "order_status" channel
+type OrderStatusEvent struct {
+	OrderId uuid.UUID `json:"orderId"`
+	Seq     int64     `json:"seq"`
+	// Resync means notifications might be lost, subscriber must re-read the history
+	Resync bool `json:"-"`
+}
+
+type OrderEventsRequest struct {
+	OrderId uuid.UUID `json:"orderId"`
+	UserId  uuid.UUID `json:"userId"`
+	Role    Role      `json:"role"`
+}
+
+// UpdateOrderStatusRequest moves the order through the fulfilment, accepting, rejecting and
+// cancelling have their own endpoints
+type UpdateOrderStatusRequest struct {
+	OrderID   uuid.UUID   `json:"-"`
+	Status    OrderStatus `json:"status" validate:"required,oneof=PREPARING PICKED_UP DELIVERED"`
+	ChangedBy uuid.UUID   `json:"-"`
+	Role      Role        `json:"-"`
+}
+
+type UpdateOrderStatusResponse struct {
+	OrderId     uuid.UUID   `json:"orderId"`
+	OrderStatus OrderStatus `json:"orderStatus"`
+}
+
 // Order struct
 type Order struct {
 	OrderID            uuid.UUID       `json:"orderId" db:"orderId"`
@@ -76,6 +173,65 @@ func (o Order) ToUserOrderData() UserOrderData {
 
 type OrderDetail []OrderData
 
+type GetOrderDetailRequest struct {
+	OrderId uuid.UUID `json:"orderId"`
+	UserId  uuid.UUID `json:"userId"`
+	Role    Role      `json:"role"`
+}
+
+type GetOrderDetailResponse struct {
+	OrderId                        uuid.UUID             `json:"orderId"`
+	OrderStatus                    OrderStatus           `json:"orderStatus"`
+	Orders                         []OrderDataSubtotal   `json:"orders"`
+	CalculatedEstimateId           uuid.UUID             `json:"calculatedEstimateId"`
+	TotalPrice                     int                   `json:"totalPrice"`
+	PriceBreakdown                 PriceBreakdown        `json:"priceBreakdown"`
+	VisitOrder                     []string              `json:"visitOrder"`
+	DeliveryTimeBreakdown          DeliveryTimeBreakdown `json:"deliveryTimeBreakdown"`
+	EstimatedDeliveryTimeInMinutes int                   `json:"estimatedDeliveryTimeInMinutes"`
+	UserLocation                   UserLocation          `json:"userLocation"`
+	StatusHistory                  []OrderStatusHistory  `json:"statusHistory"`
+	CreatedAt                      time.Time             `json:"createdAt"`
+}
+
+// OrderDataSubtotal is a merchant leg of the order with the sum of its items price
+type OrderDataSubtotal struct {
+	OrderData
+	Subtotal int `json:"subtotal"`
+}
+
+type MerchantOrdersParams struct {
+	MerchantId uuid.UUID   `json:"merchantId"`
+	Status     OrderStatus `json:"status"`
+	Limit      int         `json:"limit"`
+	Offset     int         `json:"offset"`
+}
+
+type GetMerchantOrdersResponse []MerchantOrderData
+
+// MerchantOrderData is an order seen from one merchant, only contain the merchant leg
+type MerchantOrderData struct {
+	OrderId      uuid.UUID    `json:"orderId"`
+	OrderStatus  OrderStatus  `json:"orderStatus"`
+	Order        OrderData    `json:"order"`
+	UserLocation UserLocation `json:"userLocation"`
+	CreatedAt    time.Time    `json:"createdAt"`
+}
+
+type MerchantOrderActionRequest struct {
+	MerchantId uuid.UUID `json:"merchantId"`
+	OrderId    uuid.UUID `json:"orderId"`
+	ChangedBy  uuid.UUID `json:"changedBy"`
+}
+
+type MerchantOrderActionResponse struct {
+	OrderId                        uuid.UUID   `json:"orderId"`
+	OrderStatus                    OrderStatus `json:"orderStatus"`
+	MerchantStatus                 OrderStatus `json:"merchantStatus"`
+	TotalPrice                     int         `json:"totalPrice"`
+	EstimatedDeliveryTimeInMinutes int         `json:"estimatedDeliveryTimeInMinutes"`
+}
+
 type UserOrdersParams struct {
 	UserID           uuid.UUID         `json:"userId"`
 	Status           OrderStatus       `json:"status"`
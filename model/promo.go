@@ -0,0 +1,114 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type PromoDiscountType string
+
+const (
+	PromoDiscountPercentage PromoDiscountType = "percentage"
+	PromoDiscountFixed      PromoDiscountType = "fixed"
+)
+
+// PromoCode is a discount applied at estimate time, empty scope arrays means no restriction
+type PromoCode struct {
+	ID            uuid.UUID         `json:"promoCodeId" db:"id"`
+	Code          string            `json:"code" db:"code"`
+	DiscountType  PromoDiscountType `json:"discountType" db:"discountType"`
+	DiscountValue int               `json:"discountValue" db:"discountValue"`
+	// MaxDiscount cap the percentage discount, nil means no cap
+	MaxDiscount        *int           `json:"maxDiscount" db:"maxDiscount"`
+	MinSpend           int            `json:"minSpend" db:"minSpend"`
+	PerUserLimit       *int           `json:"perUserLimit" db:"perUserLimit"`
+	UsageLimit         *int           `json:"usageLimit" db:"usageLimit"`
+	UsedCount          int            `json:"usedCount" db:"usedCount"`
+	ValidFrom          time.Time      `json:"validFrom" db:"validFrom"`
+	ValidTo            time.Time      `json:"validTo" db:"validTo"`
+	MerchantIds        pq.StringArray `json:"merchantIds" db:"merchantIds"`
+	MerchantCategories pq.StringArray `json:"merchantCategories" db:"merchantCategories"`
+	ItemCategories     pq.StringArray `json:"itemCategories" db:"itemCategories"`
+	CreatedBy          *uuid.UUID     `json:"createdBy,omitempty" db:"createdBy"`
+	CreatedAt          time.Time      `json:"createdAt" db:"createdAt"`
+}
+
+// IsValidAt reports whether t is inside the validity window of the promo
+func (p PromoCode) IsValidAt(t time.Time) bool {
+	return !p.ValidFrom.After(t) && p.ValidTo.After(t)
+}
+
+// IsExhausted reports whether the promo reached its total usage limit
+func (p PromoCode) IsExhausted() bool {
+	return p.UsageLimit != nil && p.UsedCount >= *p.UsageLimit
+}
+
+// AppliesTo reports whether the item of the merchant is in the scope of the promo
+func (p PromoCode) AppliesTo(merchant Merchant, item BoughtItem) bool {
+	return inScope(p.MerchantIds, merchant.ID.String()) &&
+		inScope(p.MerchantCategories, string(merchant.Category)) &&
+		inScope(p.ItemCategories, item.ProductCategory)
+}
+
+func inScope(scope []string, value string) bool {
+	if len(scope) == 0 {
+		return true
+	}
+	for _, s := range scope {
+		if s == value {
+			return true
+		}
+	}
+	return false
+}
+
+// PromoDiscountLeg is the part of the discount given to a merchant leg of the order
+type PromoDiscountLeg struct {
+	MerchantId uuid.UUID `json:"merchantId"`
+	// EligibleSubtotal is the price of the leg items in the promo scope
+	EligibleSubtotal int `json:"eligibleSubtotal"`
+	Discount         int `json:"discount"`
+}
+
+// AppliedPromo is the promo applied to an estimate
+type AppliedPromo struct {
+	PromoCodeId    uuid.UUID          `json:"promoCodeId"`
+	Code           string             `json:"code"`
+	DiscountAmount int                `json:"discountAmount"`
+	Breakdown      []PromoDiscountLeg `json:"breakdown"`
+}
+
+// PromoCodeUsage is counted when the order using the promo is confirmed
+type PromoCodeUsage struct {
+	ID                   uuid.UUID `json:"id" db:"id"`
+	PromoCodeId          uuid.UUID `json:"promoCodeId" db:"promoCodeId"`
+	UserId               uuid.UUID `json:"userId" db:"userId"`
+	OrderId              uuid.UUID `json:"orderId" db:"orderId"`
+	CalculatedEstimateId uuid.UUID `json:"calculatedEstimateId" db:"calculatedEstimateId"`
+	Discount             int       `json:"discount" db:"discount"`
+	CreatedAt            time.Time `json:"createdAt" db:"createdAt"`
+}
+
+type CreatePromoCodeRequest struct {
+	Code          string `json:"code" validate:"required,alphanum,min=3,max=30"`
+	DiscountType  string `json:"discountType" validate:"required,oneof=percentage fixed"`
+	DiscountValue int    `json:"discountValue" validate:"required,min=1"`
+	MaxDiscount   *int   `json:"maxDiscount" validate:"omitempty,min=1"`
+	MinSpend      int    `json:"minSpend" validate:"min=0"`
+	PerUserLimit  *int   `json:"perUserLimit" validate:"omitempty,min=1"`
+	UsageLimit    *int   `json:"usageLimit" validate:"omitempty,min=1"`
+	// ValidFrom default to now
+	ValidFrom          *time.Time `json:"validFrom"`
+	ValidTo            time.Time  `json:"validTo" validate:"required"`
+	MerchantIds        []string   `json:"merchantIds" validate:"dive,uuid"`
+	MerchantCategories []string   `json:"merchantCategories" validate:"dive,oneof=SmallRestaurant MediumRestaurant LargeRestaurant MerchandiseRestaurant BoothKiosk ConvenienceStore"`
+	ItemCategories     []string   `json:"itemCategories" validate:"dive,oneof=Beverage Food Snack Condiments Additions"`
+}
+
+type PromoCodeParams struct {
+	Code   string
+	Limit  int
+	Offset int
+}
@@ -1,32 +1,122 @@
 package model
 
 import (
+	"encoding/json"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"math"
 	"time"
 )
 
-var (
-	MaxDistanceFromStartingPoint float64 = 3 // in km
-)
-
 type EstimateOrdersRequest struct {
 	UserId       uuid.UUID      `json:"userId"`
 	UserLocation UserLocation   `json:"userLocation" validate:"required"`
 	Orders       []OrderRequest `json:"orders" validate:"required,dive"`
+	// PromoCode is optional, the estimate is rejected when the code can't be applied
+	PromoCode string `json:"promoCode" validate:"omitempty,max=30"`
 }
 
 type EstimateOrdersResponse struct {
-	TotalPrice                     int       `json:"totalPrice"`
-	EstimatedDeliveryTimeInMinutes int       `json:"estimatedDeliveryTimeInMinutes"`
-	CalculatedEstimateId           uuid.UUID `json:"calculatedEstimateId"`
+	SubtotalPrice                  int           `json:"subtotalPrice"`
+	TotalPrice                     int           `json:"totalPrice"`
+	EstimatedDeliveryTimeInMinutes int           `json:"estimatedDeliveryTimeInMinutes"`
+	CalculatedEstimateId           uuid.UUID     `json:"calculatedEstimateId"`
+	ExpiresAt                      time.Time     `json:"expiresAt"`
+	Promo                          *AppliedPromo `json:"promo,omitempty"`
+	// PriceBreakdown itemise how TotalPrice is calculated
+	PriceBreakdown PriceBreakdown `json:"priceBreakdown"`
+	// VisitOrder is the merchant ids in the order the courier visit them before the user
+	VisitOrder pq.StringArray `json:"visitOrder"`
+	// DeliveryTimeBreakdown split EstimatedDeliveryTimeInMinutes into preparation and travel
+	DeliveryTimeBreakdown DeliveryTimeBreakdown `json:"deliveryTimeBreakdown"`
+	// Traffic is the speed profile in effect for the estimate
+	Traffic Traffic `json:"traffic"`
 }
 
 type CalculatedEstimate struct {
-	TotalPrice                     int       `json:"totalPrice" db:"totalPrice"`
-	EstimatedDeliveryTimeInMinutes int       `json:"estimatedDeliveryTimeInMinutes" db:"estimatedDeliveryTimeInMinutes"`
-	CalculatedEstimateId           uuid.UUID `json:"calculatedEstimateId" db:"calculatedEstimateId"`
-	OrderId                        uuid.UUID `json:"orderId" db:"orderId"`
-	CreatedAt                      time.Time `json:"createdAt" db:"createdAt"`
+	TotalPrice                     int        `json:"totalPrice" db:"totalPrice"`
+	EstimatedDeliveryTimeInMinutes int        `json:"estimatedDeliveryTimeInMinutes" db:"estimatedDeliveryTimeInMinutes"`
+	CalculatedEstimateId           uuid.UUID  `json:"calculatedEstimateId" db:"calculatedEstimateId"`
+	OrderId                        uuid.UUID  `json:"orderId" db:"orderId"`
+	CreatedAt                      time.Time  `json:"createdAt" db:"createdAt"`
+	UserId                         uuid.UUID  `json:"userId" db:"userId"`
+	ExpiresAt                      time.Time  `json:"expiresAt" db:"expiresAt"`
+	ConfirmedAt                    *time.Time `json:"confirmedAt" db:"confirmedAt"`
+	// SubtotalPrice is the items price, TotalPrice = SubtotalPrice + fees - DiscountAmount
+	SubtotalPrice        int             `json:"subtotalPrice" db:"subtotalPrice"`
+	PromoCodeId          *uuid.UUID      `json:"promoCodeId" db:"promoCodeId"`
+	DiscountAmount       int             `json:"discountAmount" db:"discountAmount"`
+	DiscountBreakdownRaw json.RawMessage `json:"-" db:"discountBreakdown"`
+	EstimateFees
+	RouteDistanceInKm float64        `json:"routeDistanceInKm" db:"routeDistanceInKm"`
+	VisitOrder        pq.StringArray `json:"visitOrder" db:"visitOrder"`
+	// PreparationTimeInMinutes is the courier waiting for the food at the merchants,
+	// EstimatedDeliveryTimeInMinutes = PreparationTimeInMinutes + TravelTimeInMinutes
+	PreparationTimeInMinutes int `json:"preparationTimeInMinutes" db:"preparationTimeInMinutes"`
+	TravelTimeInMinutes      int `json:"travelTimeInMinutes" db:"travelTimeInMinutes"`
+}
+
+// DeliveryTimeBreakdown is the estimated delivery time split by what the courier is doing
+type DeliveryTimeBreakdown struct {
+	PreparationTimeInMinutes int `json:"preparationTimeInMinutes"`
+	TravelTimeInMinutes      int `json:"travelTimeInMinutes"`
+	TotalInMinutes           int `json:"totalInMinutes"`
+}
+
+func (c CalculatedEstimate) DeliveryTimeBreakdown() DeliveryTimeBreakdown {
+	return DeliveryTimeBreakdown{
+		PreparationTimeInMinutes: c.PreparationTimeInMinutes,
+		TravelTimeInMinutes:      c.TravelTimeInMinutes,
+		TotalInMinutes:           c.EstimatedDeliveryTimeInMinutes,
+	}
+}
+
+// SetDeliveryTime round the preparation and travel time, the components always add up to the total
+func (c *CalculatedEstimate) SetDeliveryTime(preparation, travel time.Duration) {
+	c.EstimatedDeliveryTimeInMinutes = int(math.Round((preparation + travel).Minutes()))
+	c.PreparationTimeInMinutes = int(math.Round(preparation.Minutes()))
+	c.TravelTimeInMinutes = c.EstimatedDeliveryTimeInMinutes - c.PreparationTimeInMinutes
+}
+
+// EstimateFees is charged on top of the items price for delivering the order
+type EstimateFees struct {
+	// DeliveryFee is the base fee plus the per km rate of the route length
+	DeliveryFee int `json:"deliveryFee" db:"deliveryFee"`
+	// ExtraMerchantFee is the surcharge for every merchant after the first one
+	ExtraMerchantFee int `json:"extraMerchantFee" db:"extraMerchantFee"`
+	// SmallOrderFee is charged when the subtotal is below the small order threshold
+	SmallOrderFee int `json:"smallOrderFee" db:"smallOrderFee"`
+}
+
+func (f EstimateFees) Total() int {
+	return f.DeliveryFee + f.ExtraMerchantFee + f.SmallOrderFee
+}
+
+// PriceBreakdown is the itemised total price, Total = Subtotal + fees - Discount
+type PriceBreakdown struct {
+	Subtotal int `json:"subtotal"`
+	EstimateFees
+	Discount          int     `json:"discount"`
+	Total             int     `json:"total"`
+	RouteDistanceInKm float64 `json:"routeDistanceInKm"`
+}
+
+func (c CalculatedEstimate) PriceBreakdown() PriceBreakdown {
+	return PriceBreakdown{
+		Subtotal:          c.SubtotalPrice,
+		EstimateFees:      c.EstimateFees,
+		Discount:          c.DiscountAmount,
+		Total:             c.TotalPrice,
+		RouteDistanceInKm: c.RouteDistanceInKm,
+	}
+}
+
+// ItemError describe why an item in the estimate request is rejected
+type ItemError struct {
+	MerchantId string `json:"merchantId"`
+	ItemId     string `json:"itemId"`
+	Name       string `json:"name,omitempty"`
+	Reason     string `json:"reason"`
 }
 
 type UserLocation struct {
@@ -35,6 +125,7 @@ type UserLocation struct {
 }
 
 type ConfirmOrderRequest struct {
+	UserId               uuid.UUID `json:"userId"`
 	CalculatedEstimateId uuid.UUID `json:"calculatedEstimateId" validate:"required"`
 }
 
@@ -42,6 +133,51 @@ type ConfirmOrderResponse struct {
 	OrderId uuid.UUID `json:"orderId"`
 }
 
+// OrderChanges is the difference between the items snapshot in the estimate and the current catalog
+type OrderChanges struct {
+	ChangedPrices    []ChangedItemPrice `json:"changedPrices"`
+	MissingItems     []MissingItem      `json:"missingItems"`
+	UnavailableItems []MissingItem      `json:"unavailableItems"`
+}
+
+type ChangedItemPrice struct {
+	MerchantId     uuid.UUID `json:"merchantId"`
+	ItemId         uuid.UUID `json:"itemId"`
+	Name           string    `json:"name"`
+	EstimatedPrice int       `json:"estimatedPrice"`
+	CurrentPrice   int       `json:"currentPrice"`
+}
+
+type MissingItem struct {
+	MerchantId uuid.UUID `json:"merchantId"`
+	ItemId     uuid.UUID `json:"itemId"`
+	Name       string    `json:"name"`
+}
+
+func (c OrderChanges) IsEmpty() bool {
+	return len(c.ChangedPrices) == 0 && len(c.MissingItems) == 0 && len(c.UnavailableItems) == 0
+}
+
+type ReorderRequest struct {
+	OrderId uuid.UUID `json:"-"`
+	UserId  uuid.UUID `json:"-"`
+	// UserLocation is optional, the location of the previous order is used when empty
+	UserLocation *UserLocation `json:"userLocation"`
+}
+
+type ReorderResponse struct {
+	EstimateOrdersResponse
+	// MissingMerchants and MissingItems are left out of the new estimate because they no longer exist
+	MissingMerchants []MissingMerchant `json:"missingMerchants"`
+	MissingItems     []MissingItem     `json:"missingItems"`
+	UnavailableItems []MissingItem     `json:"unavailableItems"`
+}
+
+type MissingMerchant struct {
+	MerchantId uuid.UUID `json:"merchantId"`
+	Name       string    `json:"name"`
+}
+
 type GetUserOrdersRequest struct {
 	MerchantId *string
 	Limit      *int
@@ -55,3 +191,19 @@ type Point struct {
 	Lat float64 // Latitude
 	Lon float64 // Longitude
 }
+
+// Traffic is the courier speed in effect for a delivery
+type Traffic struct {
+	// SpeedProfile is the name of the profile in effect, empty means the free flow speed
+	SpeedProfile   string  `json:"speedProfile,omitempty"`
+	SpeedKmPerHour float64 `json:"speedKmPerHour"`
+	FeeMultiplier  float64 `json:"feeMultiplier"`
+}
+
+// Route is the travel through the waypoints in their order
+type Route struct {
+	DistanceKm float64
+	Duration   time.Duration
+	// LegDurations is the travel time from each waypoint to the next one
+	LegDurations []time.Duration
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CancelReasonCode string
+
+// enum of cancel reason, user and merchant have their own set of reason
+const (
+	CancelReasonChangedMind      CancelReasonCode = "CHANGED_MIND"
+	CancelReasonOrderedByMistake CancelReasonCode = "ORDERED_BY_MISTAKE"
+	CancelReasonDeliveryTooLong  CancelReasonCode = "DELIVERY_TOO_LONG"
+	CancelReasonOutOfStock       CancelReasonCode = "OUT_OF_STOCK"
+	CancelReasonMerchantClosed   CancelReasonCode = "MERCHANT_CLOSED"
+	CancelReasonMerchantTooBusy  CancelReasonCode = "MERCHANT_TOO_BUSY"
+	CancelReasonOther            CancelReasonCode = "OTHER"
+)
+
+type CancelInitiator string
+
+const (
+	CancelInitiatorUser     CancelInitiator = "user"
+	CancelInitiatorMerchant CancelInitiator = "merchant"
+)
+
+type CancelOrderRequest struct {
+	OrderId     uuid.UUID        `json:"-"`
+	MerchantId  uuid.UUID        `json:"-"`
+	ReasonCode  CancelReasonCode `json:"reasonCode" validate:"required"`
+	Note        string           `json:"note" validate:"max=255"`
+	CancelledBy uuid.UUID        `json:"-"`
+	Role        Role             `json:"-"`
+}
+
+type CancelOrderResponse struct {
+	OrderId      uuid.UUID   `json:"orderId"`
+	OrderStatus  OrderStatus `json:"orderStatus"`
+	RefundId     uuid.UUID   `json:"refundId"`
+	RefundAmount int         `json:"refundAmount"`
+}
+
+// RefundLedger is an append only record of money that should go back to the user
+type RefundLedger struct {
+	ID                   uuid.UUID        `json:"id" db:"id"`
+	OrderID              uuid.UUID        `json:"orderId" db:"orderId"`
+	CalculatedEstimateId uuid.UUID        `json:"calculatedEstimateId" db:"calculatedEstimateId"`
+	Amount               int              `json:"amount" db:"amount"`
+	ReasonCode           CancelReasonCode `json:"reasonCode" db:"reasonCode"`
+	Note                 string           `json:"note" db:"note"`
+	Initiator            CancelInitiator  `json:"initiator" db:"initiator"`
+	InitiatedBy          uuid.UUID        `json:"initiatedBy" db:"initiatedBy"`
+	CreatedAt            time.Time        `json:"createdAt" db:"createdAt"`
+}
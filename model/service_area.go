@@ -0,0 +1,143 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ServiceArea is a city level area the delivery operates in, Geometry is a GeoJSON Polygon or MultiPolygon
+type ServiceArea struct {
+	ID        uuid.UUID       `json:"id" db:"id"`
+	Name      string          `json:"name" db:"name"`
+	Geometry  json.RawMessage `json:"geometry" db:"geometry"`
+	CreatedAt time.Time       `json:"createdAt" db:"createdAt"`
+}
+
+type CreateServiceAreaRequest struct {
+	Name string `json:"name" validate:"required,min=2,max=50"`
+	// Geometry is a GeoJSON Polygon or MultiPolygon, or a Feature of one, e.g. drawn on geojson.io
+	Geometry json.RawMessage `json:"geometry" validate:"required"`
+}
+
+// OutOfRangeMerchant is reported when a merchant of the order doesn't deliver to the user location
+type OutOfRangeMerchant struct {
+	MerchantId       uuid.UUID `json:"merchantId"`
+	Name             string    `json:"name"`
+	DistanceKm       float64   `json:"distanceKm"`
+	DeliveryRadiusKm float64   `json:"deliveryRadiusKm"`
+	// OutsideServiceArea means the merchant isn't in the service area of the user location
+	OutsideServiceArea bool `json:"outsideServiceArea"`
+}
+
+// Polygon is the rings of [longitude, latitude] positions, the first ring is the outer boundary
+// and the others are holes
+type Polygon [][][]float64
+
+// MultiPolygon is the polygons of a service area
+type MultiPolygon []Polygon
+
+type geoJSONObject struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+	Geometry    json.RawMessage `json:"geometry"`
+}
+
+// ParseGeometry parse a GeoJSON Polygon, MultiPolygon or a Feature of one.
+// geometry is the bare geometry of raw, it is what is stored.
+func ParseGeometry(raw json.RawMessage) (polygons MultiPolygon, geometry json.RawMessage, err error) {
+	var object geoJSONObject
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return nil, nil, fmt.Errorf("invalid GeoJSON: %w", err)
+	}
+	if object.Type == "Feature" {
+		return ParseGeometry(object.Geometry)
+	}
+
+	switch object.Type {
+	case "Polygon":
+		var polygon Polygon
+		if err := json.Unmarshal(object.Coordinates, &polygon); err != nil {
+			return nil, nil, fmt.Errorf("invalid Polygon coordinates: %w", err)
+		}
+		polygons = MultiPolygon{polygon}
+	case "MultiPolygon":
+		if err := json.Unmarshal(object.Coordinates, &polygons); err != nil {
+			return nil, nil, fmt.Errorf("invalid MultiPolygon coordinates: %w", err)
+		}
+	default:
+		return nil, nil, fmt.Errorf("GeoJSON type must be Polygon or MultiPolygon, got %q", object.Type)
+	}
+
+	if len(polygons) == 0 {
+		return nil, nil, errors.New("GeoJSON has no polygon")
+	}
+	for _, polygon := range polygons {
+		if len(polygon) == 0 {
+			return nil, nil, errors.New("polygon must have an outer ring")
+		}
+		for _, ring := range polygon {
+			if err := validateRing(ring); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	geometry, err = json.Marshal(map[string]interface{}{"type": object.Type, "coordinates": object.Coordinates})
+	return polygons, geometry, err
+}
+
+func validateRing(ring [][]float64) error {
+	if len(ring) < 4 {
+		return errors.New("polygon ring must have at least 4 positions")
+	}
+	for _, position := range ring {
+		if len(position) < 2 || position[0] < -180 || position[0] > 180 || position[1] < -90 || position[1] > 90 {
+			return errors.New("polygon position must be [longitude, latitude]")
+		}
+	}
+	first, last := ring[0], ring[len(ring)-1]
+	if first[0] != last[0] || first[1] != last[1] {
+		return errors.New("polygon ring must end at its first position")
+	}
+	return nil
+}
+
+// Contains reports whether the point is inside any of the polygons
+func (m MultiPolygon) Contains(lat, long float64) bool {
+	for _, polygon := range m {
+		if polygon.Contains(lat, long) {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains reports whether the point is inside the outer ring and outside every hole
+func (p Polygon) Contains(lat, long float64) bool {
+	if len(p) == 0 || !ringContains(p[0], lat, long) {
+		return false
+	}
+	for _, hole := range p[1:] {
+		if ringContains(hole, lat, long) {
+			return false
+		}
+	}
+	return true
+}
+
+// ringContains cast a ray from the point and count the edges it crosses, odd means inside
+func ringContains(ring [][]float64, lat, long float64) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > lat) != (yj > lat) && long < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
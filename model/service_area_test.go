@@ -0,0 +1,78 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// a 10x10 square around (5, 5) with a 2x2 hole around (5, 5), and a 1x1 square around (20.5, 20.5)
+const testServiceArea = `{"type":"MultiPolygon","coordinates":[
+	[[[0,0],[10,0],[10,10],[0,10],[0,0]],[[4,4],[6,4],[6,6],[4,6],[4,4]]],
+	[[[20,20],[21,20],[21,21],[20,21],[20,20]]]
+]}`
+
+func TestMultiPolygonContains(t *testing.T) {
+	area, _, err := ParseGeometry(json.RawMessage(testServiceArea))
+	if err != nil {
+		t.Fatalf("ParseGeometry() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		lat, long float64
+		want      bool
+	}{
+		{name: "inside the outer ring", lat: 2, long: 2, want: true},
+		{name: "inside the hole", lat: 5, long: 5, want: false},
+		{name: "between the hole and the boundary", lat: 5, long: 8, want: true},
+		{name: "inside the second polygon", lat: 20.5, long: 20.5, want: true},
+		{name: "between the polygons", lat: 15, long: 15, want: false},
+		{name: "outside", lat: -1, long: 5, want: false},
+		{name: "latitude and longitude not swapped", lat: 5, long: 20.5, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := area.Contains(tt.lat, tt.long); got != tt.want {
+				t.Errorf("Contains(%v, %v) = %v, want %v", tt.lat, tt.long, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGeometry(t *testing.T) {
+	square := `[[[0,0],[1,0],[1,1],[0,1],[0,0]]]`
+	tests := []struct {
+		name         string
+		raw          string
+		wantPolygons int
+		wantErr      bool
+	}{
+		{name: "polygon", raw: `{"type":"Polygon","coordinates":` + square + `}`, wantPolygons: 1},
+		{name: "multi polygon", raw: testServiceArea, wantPolygons: 2},
+		{name: "feature", raw: `{"type":"Feature","properties":{},"geometry":{"type":"Polygon","coordinates":` + square + `}}`, wantPolygons: 1},
+		{name: "point", raw: `{"type":"Point","coordinates":[0,0]}`, wantErr: true},
+		{name: "open ring", raw: `{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,1],[0,0.5]]]}`, wantErr: true},
+		{name: "too few positions", raw: `{"type":"Polygon","coordinates":[[[0,0],[1,0],[0,0]]]}`, wantErr: true},
+		{name: "latitude out of range", raw: `{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,91],[0,1],[0,0]]]}`, wantErr: true},
+		{name: "empty multi polygon", raw: `{"type":"MultiPolygon","coordinates":[]}`, wantErr: true},
+		{name: "invalid json", raw: `{"type":`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			polygons, geometry, err := ParseGeometry(json.RawMessage(tt.raw))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseGeometry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(polygons) != tt.wantPolygons {
+				t.Errorf("ParseGeometry() polygons = %d, want %d", len(polygons), tt.wantPolygons)
+			}
+			// the stored geometry is the bare geometry, a Feature is unwrapped
+			if _, _, err := ParseGeometry(geometry); err != nil {
+				t.Errorf("ParseGeometry() geometry %s doesn't parse again: %v", geometry, err)
+			}
+		})
+	}
+}
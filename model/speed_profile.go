@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// SpeedProfilePreviewRequest preview a delivery route under the speed profiles
+type SpeedProfilePreviewRequest struct {
+	// Merchants are the locations to pick up from, the first one is the starting point
+	Merchants    []Location   `json:"merchants" validate:"required,min=1,dive"`
+	UserLocation UserLocation `json:"userLocation" validate:"required"`
+	// At is the time to preview, default to now
+	At *time.Time `json:"at"`
+	// Profiles are the names of the profiles to preview, default to every profile
+	Profiles []string `json:"profiles"`
+}
+
+type SpeedProfilePreview struct {
+	Traffic
+	// InEffect is the profile a real estimate of the route would use at that time
+	InEffect                       bool `json:"inEffect"`
+	EstimatedDeliveryTimeInMinutes int  `json:"estimatedDeliveryTimeInMinutes"`
+	DeliveryFee                    int  `json:"deliveryFee"`
+}
+
+type SpeedProfilePreviewResponse struct {
+	At                time.Time `json:"at"`
+	RouteDistanceInKm float64   `json:"routeDistanceInKm"`
+	// Previews always start with the free flow speed, then the profiles
+	Previews []SpeedProfilePreview `json:"previews"`
+}
@@ -12,8 +12,15 @@ const (
 	RoleAll        = Role("all")
 	RoleAdmin Role = "admin"
 	RoleUser  Role = "user"
+	// RoleSuperAdmin pass every admin check and can manage every merchant
+	RoleSuperAdmin Role = "super_admin"
 )
 
+// IsAdmin reports whether the role can access the admin endpoints
+func (r Role) IsAdmin() bool {
+	return r == RoleAdmin || r == RoleSuperAdmin
+}
+
 type Staff struct {
 	ID        uuid.UUID `json:"id" db:"id"`
 	Username  string    `json:"username" db:"username"`
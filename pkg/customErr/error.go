@@ -3,8 +3,9 @@ package customErr
 import "errors"
 
 type CustomError struct {
-	Message    string `json:"message"`
-	StatusCode int    `json:"status"`
+	Message    string      `json:"message"`
+	StatusCode int         `json:"status"`
+	Data       interface{} `json:"data,omitempty"`
 }
 
 // New creates a new CustomError instance with the specified code and message
@@ -15,6 +16,25 @@ func New(code int, message string) *CustomError {
 	}
 }
 
+// NewWithData creates a new CustomError instance that carry detail of the error, e.g. list of invalid items
+func NewWithData(code int, message string, data interface{}) *CustomError {
+	return &CustomError{
+		StatusCode: code,
+		Message:    message,
+		Data:       data,
+	}
+}
+
+// GetData returns the detail of a CustomError, nil for any other error
+func GetData(err error) interface{} {
+	var cerr *CustomError
+	ok := errors.As(err, &cerr)
+	if !ok {
+		return nil
+	}
+	return cerr.Data
+}
+
 func GetCode(err error) int {
 	var cerr *CustomError
 	ok := errors.As(err, &cerr)
@@ -0,0 +1,135 @@
+package repo
+
+import (
+	"beli-mang/model"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// catalogFetchSize is the number of rows fetched from the cursor at a time
+const catalogFetchSize = 500
+
+// StreamCatalog read the catalog through a server-side cursor and call fn for every row,
+// so the memory usage doesn't grow with the catalog size. Returning an error from fn stops the stream.
+func (r *merchantRepository) StreamCatalog(ctx context.Context, params model.CatalogExportParams, fn func(model.CatalogRow) error) (err error) {
+	query, args := buildCatalogQuery(params)
+
+	// cursor only lives inside a transaction
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, `DECLARE catalog_cursor NO SCROLL CURSOR FOR `+query, args...); err != nil {
+		return err
+	}
+
+	fetchQuery := fmt.Sprintf(`FETCH %d FROM catalog_cursor`, catalogFetchSize)
+	for {
+		fetched, err := fetchCatalogRows(ctx, tx, fetchQuery, fn)
+		if err != nil {
+			return err
+		}
+		if fetched < catalogFetchSize {
+			return nil
+		}
+	}
+}
+
+func fetchCatalogRows(ctx context.Context, tx *sqlx.Tx, fetchQuery string, fn func(model.CatalogRow) error) (fetched int, err error) {
+	rows, err := tx.QueryxContext(ctx, fetchQuery)
+	if err != nil {
+		return 0, err
+	}
+	defer func(rows *sqlx.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	for rows.Next() {
+		var row model.CatalogRow
+		var (
+			itemId          *uuid.UUID
+			itemName        *string
+			itemCategory    *string
+			itemImageURL    *string
+			itemPrice       *int
+			itemPriceId     *uuid.UUID
+			itemIsAvailable *bool
+			itemCreatedAt   *time.Time
+		)
+		if err := rows.Scan(&row.Merchant.ID, &row.Merchant.Name, &row.Merchant.Category, &row.Merchant.ImageURL,
+			&row.Merchant.Location.Lat, &row.Merchant.Location.Long, &row.Merchant.CreatedAt,
+			&itemId, &itemName, &itemCategory, &itemImageURL, &itemPrice, &itemPriceId, &itemIsAvailable, &itemCreatedAt); err != nil {
+			return fetched, err
+		}
+		if itemId != nil {
+			row.Item = &model.MerchantItem{
+				ID:          *itemId,
+				MerchantId:  row.Merchant.ID,
+				Name:        *itemName,
+				Category:    *itemCategory,
+				ImageURL:    *itemImageURL,
+				Price:       *itemPrice,
+				PriceId:     itemPriceId,
+				IsAvailable: *itemIsAvailable,
+				CreatedAt:   *itemCreatedAt,
+			}
+		}
+		fetched++
+		if err := fn(row); err != nil {
+			return fetched, err
+		}
+	}
+	return fetched, rows.Err()
+}
+
+func buildCatalogQuery(params model.CatalogExportParams) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	conditions = append(conditions, `m."deletedAt" IS NULL`)
+	if params.MerchantId != nil {
+		addCondition(`m.id = $%d`, *params.MerchantId)
+	}
+	if params.MerchantCategory != "" {
+		addCondition(`m.category = $%d`, params.MerchantCategory)
+	}
+	if params.StaffId != "" {
+		addCondition(`m.id IN (SELECT "merchantId" FROM "merchantStaff" WHERE "staffId" = $%d)`, params.StaffId)
+	}
+	if params.ItemCategory != "" {
+		addCondition(`i.category = $%d`, params.ItemCategory)
+	}
+	if params.CreatedAtFrom != nil {
+		addCondition(`i."createdAt" >= $%d`, *params.CreatedAtFrom)
+	}
+	if params.CreatedAtTo != nil {
+		addCondition(`i."createdAt" < $%d`, *params.CreatedAtTo)
+	}
+
+	query := `
+	SELECT m.id, m.name, m.category, m."imageUrl", m.latitude, m.longitude, m."createdAt",
+	       i.id, i.name, i.category, i."imageUrl", ` + currentItemPriceColumns("i") + `, i."isAvailable", i."createdAt"
+	FROM "merchant" m
+	LEFT JOIN "merchantItem" i ON i."merchantId" = m.id AND i."deletedAt" IS NULL
+	WHERE ` + strings.Join(conditions, " AND ") + `
+	ORDER BY m."createdAt", m.id, i."createdAt", i.id`
+	return query, args
+}
@@ -0,0 +1,95 @@
+package repo
+
+import (
+	"beli-mang/model"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type IdempotencyRepository interface {
+	Reserve(ctx context.Context, record model.IdempotencyRecord) (reserved bool, err error)
+	Get(ctx context.Context, userId uuid.UUID, key string) (model.IdempotencyRecord, error)
+	SaveResponse(ctx context.Context, record model.IdempotencyRecord) error
+	Delete(ctx context.Context, userId uuid.UUID, key string) error
+	DeleteExpired(ctx context.Context, expiredBefore time.Time, limit int) (int64, error)
+}
+
+type idempotencyRepository struct {
+	db *sqlx.DB
+}
+
+func NewIdempotencyRepository(db *sqlx.DB) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+var (
+	// reserve the key, an expired key can be taken over by a new request
+	reserveIdempotencyKeyQuery = `
+	INSERT INTO "idempotencyKey" ("key", "userId", "requestHash", "createdAt", "expiresAt")
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT ("userId", "key") DO UPDATE
+	SET "requestHash" = EXCLUDED."requestHash",
+		"statusCode" = NULL,
+		"contentType" = '',
+		"responseBody" = NULL,
+		"createdAt" = EXCLUDED."createdAt",
+		"expiresAt" = EXCLUDED."expiresAt"
+	WHERE "idempotencyKey"."expiresAt" < EXCLUDED."createdAt"
+	RETURNING "key";
+`
+)
+
+// Reserve claims the key for the current request, it returns false when
+// the key is already used by another request that is not expired yet
+func (r *idempotencyRepository) Reserve(ctx context.Context, record model.IdempotencyRecord) (reserved bool, err error) {
+	var key string
+	err = r.db.QueryRowxContext(ctx, reserveIdempotencyKeyQuery, record.Key, record.UserId, record.RequestHash, record.CreatedAt, record.ExpiresAt).Scan(&key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *idempotencyRepository) Get(ctx context.Context, userId uuid.UUID, key string) (model.IdempotencyRecord, error) {
+	var record model.IdempotencyRecord
+	query := `SELECT * FROM "idempotencyKey" WHERE "userId" = $1 AND "key" = $2`
+	err := r.db.GetContext(ctx, &record, query, userId, key)
+	return record, err
+}
+
+func (r *idempotencyRepository) SaveResponse(ctx context.Context, record model.IdempotencyRecord) error {
+	query := `UPDATE "idempotencyKey" SET "statusCode" = $3, "contentType" = $4, "responseBody" = $5, "expiresAt" = $6 WHERE "userId" = $1 AND "key" = $2`
+	_, err := r.db.ExecContext(ctx, query, record.UserId, record.Key, record.StatusCode, record.ContentType, record.ResponseBody, record.ExpiresAt)
+	return err
+}
+
+func (r *idempotencyRepository) Delete(ctx context.Context, userId uuid.UUID, key string) error {
+	query := `DELETE FROM "idempotencyKey" WHERE "userId" = $1 AND "key" = $2`
+	_, err := r.db.ExecContext(ctx, query, userId, key)
+	return err
+}
+
+// DeleteExpired delete at most `limit` keys expired before the given time, locked rows are skipped
+// so it never blocks a request taking over an expired key.
+func (r *idempotencyRepository) DeleteExpired(ctx context.Context, expiredBefore time.Time, limit int) (int64, error) {
+	query := `DELETE FROM "idempotencyKey" WHERE ("userId", "key") IN (
+		SELECT "userId", "key" FROM "idempotencyKey"
+		WHERE "expiresAt" < $1
+		ORDER BY "expiresAt" ASC
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	)`
+	res, err := r.db.ExecContext(ctx, query, expiredBefore, limit)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
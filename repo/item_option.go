@@ -0,0 +1,89 @@
+package repo
+
+import (
+	"beli-mang/model"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+var (
+	getItemOptionGroupsQuery = `
+	SELECT id, "itemId", name, "minSelect", "maxSelect" FROM "itemOptionGroup"
+	WHERE "itemId" = ANY($1::uuid[])
+	ORDER BY "sortOrder";
+`
+	getItemOptionsQuery = `
+	SELECT o.id, o."groupId", o.name, o."priceDelta", o."linkedItemId", o."isAvailable" FROM "itemOption" o
+	JOIN "itemOptionGroup" g ON g.id = o."groupId"
+	WHERE g."itemId" = ANY($1::uuid[])
+	ORDER BY o."sortOrder";
+`
+	deleteItemOptionGroupsQuery = `DELETE FROM "itemOptionGroup" WHERE "itemId" = $1`
+	insertItemOptionGroupQuery  = `
+	INSERT INTO "itemOptionGroup" (id, "itemId", name, "minSelect", "maxSelect", "sortOrder")
+	VALUES ($1, $2, $3, $4, $5, $6);
+`
+	insertItemOptionQuery = `
+	INSERT INTO "itemOption" (id, "groupId", name, "priceDelta", "linkedItemId", "isAvailable", "sortOrder")
+	VALUES ($1, $2, $3, $4, $5, $6, $7);
+`
+)
+
+// GetItemOptionGroupMapByItemIds returns the option groups with their options, keyed by item id
+func (r *merchantRepository) GetItemOptionGroupMapByItemIds(ctx context.Context, itemIds []uuid.UUID) (map[uuid.UUID][]model.ItemOptionGroup, error) {
+	mapGroups := make(map[uuid.UUID][]model.ItemOptionGroup)
+	if len(itemIds) == 0 {
+		return mapGroups, nil
+	}
+	ids := uuidArray(itemIds)
+
+	var groups []model.ItemOptionGroup
+	if err := r.db.SelectContext(ctx, &groups, getItemOptionGroupsQuery, ids); err != nil {
+		return mapGroups, err
+	}
+	var options []model.ItemOption
+	if err := r.db.SelectContext(ctx, &options, getItemOptionsQuery, ids); err != nil {
+		return mapGroups, err
+	}
+
+	mapOptions := make(map[uuid.UUID][]model.ItemOption)
+	for _, option := range options {
+		mapOptions[option.GroupId] = append(mapOptions[option.GroupId], option)
+	}
+	for _, group := range groups {
+		group.Options = mapOptions[group.ID]
+		mapGroups[group.ItemId] = append(mapGroups[group.ItemId], group)
+	}
+	return mapGroups, nil
+}
+
+// ReplaceItemOptionGroups overwrite every option group of the item, order detail keeps its own option snapshot
+func (r *merchantRepository) ReplaceItemOptionGroups(ctx context.Context, itemId uuid.UUID, groups []model.ItemOptionGroup) (err error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, deleteItemOptionGroupsQuery, itemId); err != nil {
+		return err
+	}
+	for i, group := range groups {
+		if _, err = tx.ExecContext(ctx, insertItemOptionGroupQuery, group.ID, itemId, group.Name, group.MinSelect, group.MaxSelect, i); err != nil {
+			return err
+		}
+		for j, option := range group.Options {
+			if _, err = tx.ExecContext(ctx, insertItemOptionQuery, option.ID, group.ID, option.Name, option.PriceDelta, option.LinkedItemId, option.IsAvailable, j); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package repo
+
+import (
+	"beli-mang/model"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+var (
+	getItemPricesQuery = `
+	SELECT id, "itemId", price, "effectiveFrom", "effectiveTo", "createdBy", "createdAt" FROM "itemPrice"
+	WHERE "itemId" = $1
+	ORDER BY "effectiveFrom", "createdAt";
+`
+	insertItemPriceQuery = `
+	INSERT INTO "itemPrice" (id, "itemId", price, "effectiveFrom", "effectiveTo", "createdBy", "createdAt")
+	VALUES ($1, $2, $3, $4, $5, $6, $7);
+`
+)
+
+// GetItemPrices returns the price timeline of the item ordered by effectiveFrom
+func (r *merchantRepository) GetItemPrices(ctx context.Context, itemId uuid.UUID) ([]model.ItemPrice, error) {
+	prices := []model.ItemPrice{}
+	err := r.db.SelectContext(ctx, &prices, getItemPricesQuery, itemId)
+	return prices, err
+}
+
+func (r *merchantRepository) InsertItemPrice(ctx context.Context, price model.ItemPrice) error {
+	_, err := r.db.ExecContext(ctx, insertItemPriceQuery, price.ID, price.ItemId, price.Price, price.EffectiveFrom, price.EffectiveTo, price.CreatedBy, price.CreatedAt)
+	return err
+}
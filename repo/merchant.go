@@ -6,18 +6,35 @@ import (
 	"fmt"
 	"github.com/google/uuid"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type MerchantRepository interface {
-	CreateMerchant(request model.Merchant) error
+	CreateMerchant(ctx context.Context, request model.Merchant, owner model.MerchantStaff) error
+	IsMerchantStaff(ctx context.Context, staffId uuid.UUID, merchantIds []uuid.UUID) (bool, error)
+	IsStaffOfAllMerchants(ctx context.Context, staffId uuid.UUID, merchantIds []uuid.UUID) (bool, error)
+	GetMerchantScheduleMapByIds(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]model.MerchantSchedule, error)
+	ReplaceMerchantSchedule(ctx context.Context, schedule model.MerchantSchedule) error
 	GetMerchantMapByIds(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]model.Merchant, error)
 	GetMerchantItemMapByIds(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]model.Item, error)
 	GetMerchant(ctx context.Context, params model.GetMerchantParams) (patients []model.Merchant, meta model.MetaData, err error)
 	GetMerchantById(ctx context.Context, merchantId uuid.UUID) (merchant model.Merchant, err error)
+	UpdateMerchant(ctx context.Context, merchant model.Merchant) error
+	DeleteMerchant(ctx context.Context, merchantId uuid.UUID, deletedAt time.Time) error
 	CreateMerchantItem(request model.MerchantItem) error
+	CreateMerchantItems(ctx context.Context, items []model.MerchantItem) error
+	GetMerchantItemById(ctx context.Context, merchantId, itemId uuid.UUID) (model.MerchantItem, error)
+	UpdateMerchantItem(ctx context.Context, item model.MerchantItem, newPrice *model.ItemPrice) error
+	GetItemPrices(ctx context.Context, itemId uuid.UUID) ([]model.ItemPrice, error)
+	InsertItemPrice(ctx context.Context, price model.ItemPrice) error
+	DeleteMerchantItem(ctx context.Context, merchantId, itemId uuid.UUID, deletedAt time.Time) error
 	GetMerchantItem(ctx context.Context, params model.GetMerchantItemParams) (patients []model.MerchantItem, meta model.MetaData, err error)
+	GetItemOptionGroupMapByItemIds(ctx context.Context, itemIds []uuid.UUID) (map[uuid.UUID][]model.ItemOptionGroup, error)
+	ReplaceItemOptionGroups(ctx context.Context, itemId uuid.UUID, groups []model.ItemOptionGroup) error
+	StreamCatalog(ctx context.Context, params model.CatalogExportParams, fn func(model.CatalogRow) error) error
 }
 
 type merchantRepository struct {
@@ -29,15 +46,71 @@ func NewMerchantRepository(db *sqlx.DB) MerchantRepository {
 }
 
 var (
+	// merchantColumns is the column order scanned into model.Merchant
+	merchantColumns = `"id", "name", "category", "imageUrl", "latitude", "longitude", "createdAt", "prepTimeMinutes", "deliveryRadiusKm"`
+	// merchantItemColumns is the column order scanned into model.MerchantItem and model.Item,
+	// the price is resolved from the price history at query time
+	merchantItemColumns = `"id", "merchantId", "name", "category", "imageUrl", ` + currentItemPriceColumns(`"merchantItem"`) + `, "isAvailable", "createdAt", "prepTimeMinutes"`
+
 	createMerchantQuery = `
-	INSERT INTO merchant (id, name, category, "imageUrl", latitude, longitude, "createdAt")
-	VALUES ($1, $2, $3, $4, $5, $6, NOW())
+	INSERT INTO merchant (id, name, category, "imageUrl", latitude, longitude, "prepTimeMinutes", "deliveryRadiusKm", "createdAt")
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
 	RETURNING id;
+`
+	insertMerchantStaffQuery = `
+	INSERT INTO "merchantStaff" ("merchantId", "staffId", role, "createdAt")
+	VALUES ($1, $2, $3, $4);
+`
+	isMerchantStaffQuery = `
+	SELECT EXISTS (SELECT 1 FROM "merchantStaff" WHERE "staffId" = $1 AND "merchantId" = ANY($2::uuid[]));
+`
+	isStaffOfAllMerchantsQuery = `
+	SELECT COUNT(DISTINCT "merchantId") = cardinality(ARRAY(SELECT DISTINCT unnest($2::uuid[])))
+	FROM "merchantStaff" WHERE "staffId" = $1 AND "merchantId" = ANY($2::uuid[]);
 `
 )
 
-func (r *merchantRepository) CreateMerchant(request model.Merchant) error {
-	return r.db.QueryRowx(createMerchantQuery, request.ID, request.Name, request.Category, request.ImageURL, request.Location.Lat, request.Location.Long).Scan(&request.ID)
+// currentItemPriceColumns select the price and the price version effective now of the item table,
+// the latest effectiveFrom wins when versions overlap so a scheduled promo price overrides the regular price.
+func currentItemPriceColumns(table string) string {
+	current := `(SELECT p.%s FROM "itemPrice" p WHERE p."itemId" = ` + table + `.id AND p."effectiveFrom" <= NOW() AND (p."effectiveTo" IS NULL OR p."effectiveTo" > NOW()) ORDER BY p."effectiveFrom" DESC LIMIT 1)`
+	return fmt.Sprintf(`COALESCE(`+current+`, `+table+`.price) AS "price", `+current+` AS "priceId"`, "price", "id")
+}
+
+// CreateMerchant insert the merchant together with its owner, so a merchant is never left without staff
+func (r *merchantRepository) CreateMerchant(ctx context.Context, request model.Merchant, owner model.MerchantStaff) (err error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	err = tx.QueryRowxContext(ctx, createMerchantQuery, request.ID, request.Name, request.Category, request.ImageURL, request.Location.Lat, request.Location.Long, request.PrepTimeMinutes, request.DeliveryRadiusKm).Scan(&request.ID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, insertMerchantStaffQuery, owner.MerchantId, owner.StaffId, owner.Role, owner.CreatedAt)
+	return err
+}
+
+// IsMerchantStaff reports whether the staff manages at least one of the merchants
+func (r *merchantRepository) IsMerchantStaff(ctx context.Context, staffId uuid.UUID, merchantIds []uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowxContext(ctx, isMerchantStaffQuery, staffId, uuidArray(merchantIds)).Scan(&exists)
+	return exists, err
+}
+
+// IsStaffOfAllMerchants reports whether the staff manages every one of the merchants
+func (r *merchantRepository) IsStaffOfAllMerchants(ctx context.Context, staffId uuid.UUID, merchantIds []uuid.UUID) (bool, error) {
+	var all bool
+	err := r.db.QueryRowxContext(ctx, isStaffOfAllMerchantsQuery, staffId, uuidArray(merchantIds)).Scan(&all)
+	return all, err
 }
 
 func (r *merchantRepository) GetMerchantMapByIds(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]model.Merchant, error) {
@@ -52,7 +125,7 @@ func (r *merchantRepository) GetMerchantMapByIds(ctx context.Context, ids []uuid
 
 	// Join the placeholders with commas to form the IN clause
 	pStr := fmt.Sprintf("IN (%s)", strings.Join(placeholders, ", "))
-	getMerchantQuery := `SELECT * FROM merchant WHERE id ` + pStr
+	getMerchantQuery := `SELECT ` + merchantColumns + ` FROM merchant WHERE "deletedAt" IS NULL AND id ` + pStr
 
 	rows, err := r.db.QueryxContext(ctx, getMerchantQuery, args...)
 	if err != nil {
@@ -64,7 +137,7 @@ func (r *merchantRepository) GetMerchantMapByIds(ctx context.Context, ids []uuid
 
 	for rows.Next() {
 		var merchant model.Merchant
-		if err := rows.Scan(&merchant.ID, &merchant.Name, &merchant.Category, &merchant.ImageURL, &merchant.Location.Lat, &merchant.Location.Long, &merchant.CreatedAt); err != nil {
+		if err := rows.Scan(&merchant.ID, &merchant.Name, &merchant.Category, &merchant.ImageURL, &merchant.Location.Lat, &merchant.Location.Long, &merchant.CreatedAt, &merchant.PrepTimeMinutes, &merchant.DeliveryRadiusKm); err != nil {
 			return merchants, err
 		}
 		merchants[merchant.ID] = merchant
@@ -86,7 +159,9 @@ func (r *merchantRepository) GetMerchantItemMapByIds(ctx context.Context, ids []
 
 	// Join the placeholders with commas to form the IN clause
 	pStr := fmt.Sprintf("IN (%s)", strings.Join(placeholders, ", "))
-	var getItemQuery = `SELECT * FROM "merchantItem" WHERE id ` + pStr
+	// semi join the merchant so the items of a deleted merchant can't be ordered
+	var getItemQuery = `SELECT ` + merchantItemColumns + ` FROM "merchantItem" WHERE "deletedAt" IS NULL AND id ` + pStr + `
+	AND EXISTS (SELECT 1 FROM merchant m WHERE m.id = "merchantItem"."merchantId" AND m."deletedAt" IS NULL)`
 	rows, err := r.db.QueryxContext(ctx, getItemQuery, args...)
 	if err != nil {
 		return mapItems, err
@@ -107,19 +182,13 @@ func (r *merchantRepository) GetMerchantItemMapByIds(ctx context.Context, ids []
 
 var (
 	getMerchantByIdQuery = `
-	SELECT "id",
-     "name",
-     "category",
-     "imageUrl",
-     "latitude",
-     "longitude",
-     "createdAt" FROM "merchant" WHERE id = $1;
+	SELECT ` + merchantColumns + ` FROM "merchant" WHERE id = $1 AND "deletedAt" IS NULL;
 `
 )
 
 func (r *merchantRepository) GetMerchantById(ctx context.Context, merchantId uuid.UUID) (merchant model.Merchant, err error) {
 	err = r.db.QueryRowxContext(ctx, getMerchantByIdQuery, merchantId).
-		Scan(&merchant.ID, &merchant.Name, &merchant.Category, &merchant.ImageURL, &merchant.Location.Lat, &merchant.Location.Long, &merchant.CreatedAt)
+		Scan(&merchant.ID, &merchant.Name, &merchant.Category, &merchant.ImageURL, &merchant.Location.Lat, &merchant.Location.Long, &merchant.CreatedAt, &merchant.PrepTimeMinutes, &merchant.DeliveryRadiusKm)
 	if err != nil {
 		return
 	}
@@ -129,8 +198,9 @@ func (r *merchantRepository) GetMerchantById(ctx context.Context, merchantId uui
 
 func (r *merchantRepository) GetMerchant(ctx context.Context, params model.GetMerchantParams) (patients []model.Merchant, meta model.MetaData, err error) {
 	var listMerchant []model.Merchant
-	var getMerchantQuery = `SELECT * FROM "merchant" WHERE true`
+	var getMerchantQuery = `SELECT ` + merchantColumns + ` FROM "merchant" WHERE "deletedAt" IS NULL`
 	var total int = 0
+	var args []interface{}
 	var metaData = model.MetaData{
 		Offset: params.Offset,
 		Limit:  params.Limit,
@@ -150,6 +220,11 @@ func (r *merchantRepository) GetMerchant(ctx context.Context, params model.GetMe
 		getMerchantQuery += fmt.Sprintf(` AND "category" = '%s'`, params.MerchantCategory)
 	}
 
+	if params.StaffId != "" {
+		args = append(args, params.StaffId)
+		getMerchantQuery += fmt.Sprintf(` AND "id" IN (SELECT "merchantId" FROM "merchantStaff" WHERE "staffId" = $%d)`, len(args))
+	}
+
 	getMerchantQueryJustWithFilter := getMerchantQuery
 
 	if params.CreatedAt != "" {
@@ -167,7 +242,7 @@ func (r *merchantRepository) GetMerchant(ctx context.Context, params model.GetMe
 
 	getMerchantQuery += fmt.Sprintf(` LIMIT %d OFFSET %d`, params.Limit, params.Offset)
 
-	rows, err := r.db.QueryContext(ctx, getMerchantQuery)
+	rows, err := r.db.QueryContext(ctx, getMerchantQuery, args...)
 	if err != nil {
 		return nil, metaData, err
 	}
@@ -177,7 +252,7 @@ func (r *merchantRepository) GetMerchant(ctx context.Context, params model.GetMe
 	// Iterate over the rows and scan each row into a struct
 	for rows.Next() {
 		var merchant model.Merchant
-		if err := rows.Scan(&merchant.ID, &merchant.Name, &merchant.Category, &merchant.ImageURL, &merchant.Location.Lat, &merchant.Location.Long, &merchant.CreatedAt); err != nil {
+		if err := rows.Scan(&merchant.ID, &merchant.Name, &merchant.Category, &merchant.ImageURL, &merchant.Location.Lat, &merchant.Location.Long, &merchant.CreatedAt, &merchant.PrepTimeMinutes, &merchant.DeliveryRadiusKm); err != nil {
 			return nil, metaData, err
 		}
 		listMerchant = append(listMerchant, merchant)
@@ -186,8 +261,8 @@ func (r *merchantRepository) GetMerchant(ctx context.Context, params model.GetMe
 		return nil, metaData, err
 	}
 
-	countQuery := strings.Replace(getMerchantQueryJustWithFilter, "SELECT * FROM", "SELECT count(id) FROM", 1)
-	err = r.db.QueryRowxContext(ctx, countQuery).Scan(&total)
+	countQuery := strings.Replace(getMerchantQueryJustWithFilter, "SELECT "+merchantColumns+" FROM", "SELECT count(id) FROM", 1)
+	err = r.db.QueryRowxContext(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, metaData, err
 	}
@@ -200,20 +275,158 @@ func (r *merchantRepository) GetMerchant(ctx context.Context, params model.GetMe
 }
 
 var (
+	updateMerchantQuery = `
+	UPDATE "merchant" SET name = $2, category = $3, "imageUrl" = $4, latitude = $5, longitude = $6, "prepTimeMinutes" = $7, "deliveryRadiusKm" = $8
+	WHERE id = $1 AND "deletedAt" IS NULL;
+`
+	deleteMerchantQuery = `
+	UPDATE "merchant" SET "deletedAt" = $2
+	WHERE id = $1 AND "deletedAt" IS NULL;
+`
+)
+
+func (r *merchantRepository) UpdateMerchant(ctx context.Context, merchant model.Merchant) error {
+	res, err := r.db.ExecContext(ctx, updateMerchantQuery, merchant.ID, merchant.Name, merchant.Category, merchant.ImageURL, merchant.Location.Lat, merchant.Location.Long, merchant.PrepTimeMinutes, merchant.DeliveryRadiusKm)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+// DeleteMerchant soft delete the merchant, order history keep its own merchant snapshot
+func (r *merchantRepository) DeleteMerchant(ctx context.Context, merchantId uuid.UUID, deletedAt time.Time) error {
+	res, err := r.db.ExecContext(ctx, deleteMerchantQuery, merchantId, deletedAt)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+var (
+	// createMerchantItemQuery insert the item with its first price version
 	createMerchantItemQuery = `
-	INSERT INTO "merchantItem" (id, "merchantId", name, "category", "imageUrl", price, "createdAt")
-	VALUES ($1, $2, $3, $4, $5, $6, NOW())
-	RETURNING id;
+	WITH item AS (
+		INSERT INTO "merchantItem" (id, "merchantId", name, "category", "imageUrl", price, "prepTimeMinutes", "createdAt")
+		VALUES ($1, $2, $3, $4, $5, $6, $8, NOW())
+		RETURNING id, price, "createdAt"
+	)
+	INSERT INTO "itemPrice" (id, "itemId", price, "effectiveFrom", "createdAt")
+	SELECT $7, id, price, "createdAt", "createdAt" FROM item
+	RETURNING "itemId";
 `
 )
 
 func (r *merchantRepository) CreateMerchantItem(request model.MerchantItem) error {
-	return r.db.QueryRowx(createMerchantItemQuery, request.ID, request.MerchantId, request.Name, request.Category, request.ImageURL, request.Price).Scan(&request.ID)
+	return r.db.QueryRowx(createMerchantItemQuery, request.ID, request.MerchantId, request.Name, request.Category, request.ImageURL, request.Price, request.PriceId, request.PrepTimeMinutes).Scan(&request.ID)
+}
+
+// CreateMerchantItems insert all the items and their first price version with COPY in one transaction,
+// nothing is inserted when one of them fails
+func (r *merchantRepository) CreateMerchantItems(ctx context.Context, items []model.MerchantItem) (err error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	itemRows := make([][]interface{}, 0, len(items))
+	priceRows := make([][]interface{}, 0, len(items))
+	for _, item := range items {
+		itemRows = append(itemRows, []interface{}{item.ID, item.MerchantId, item.Name, item.Category, item.ImageURL, item.Price, item.IsAvailable, item.PrepTimeMinutes, item.CreatedAt})
+		priceRows = append(priceRows, []interface{}{item.PriceId, item.ID, item.Price, item.CreatedAt, item.CreatedAt})
+	}
+
+	err = copyIn(ctx, tx, "merchantItem", []string{"id", "merchantId", "name", "category", "imageUrl", "price", "isAvailable", "prepTimeMinutes", "createdAt"}, itemRows)
+	if err != nil {
+		return err
+	}
+	return copyIn(ctx, tx, "itemPrice", []string{"id", "itemId", "price", "effectiveFrom", "createdAt"}, priceRows)
+}
+
+// copyIn bulk insert the rows with COPY, only one COPY can run at a time in a transaction
+func copyIn(ctx context.Context, tx *sqlx.Tx, table string, columns []string, rows [][]interface{}) error {
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(table, columns...))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	for _, row := range rows {
+		if _, err := stmt.ExecContext(ctx, row...); err != nil {
+			return err
+		}
+	}
+	// flush the buffered rows
+	_, err = stmt.ExecContext(ctx)
+	return err
+}
+
+var (
+	getMerchantItemByIdQuery = `SELECT ` + merchantItemColumns + ` FROM "merchantItem" WHERE "merchantId" = $1 AND id = $2 AND "deletedAt" IS NULL`
+	updateMerchantItemQuery  = `
+	UPDATE "merchantItem" SET name = $3, category = $4, "imageUrl" = $5, "isAvailable" = $6, "prepTimeMinutes" = $7
+	WHERE "merchantId" = $1 AND id = $2 AND "deletedAt" IS NULL;
+`
+	deleteMerchantItemQuery = `
+	UPDATE "merchantItem" SET "deletedAt" = $3
+	WHERE "merchantId" = $1 AND id = $2 AND "deletedAt" IS NULL;
+`
+)
+
+func (r *merchantRepository) GetMerchantItemById(ctx context.Context, merchantId, itemId uuid.UUID) (model.MerchantItem, error) {
+	var item model.MerchantItem
+	err := r.db.GetContext(ctx, &item, getMerchantItemByIdQuery, merchantId, itemId)
+	return item, err
+}
+
+// UpdateMerchantItem update the item detail, price is never overwritten,
+// a price change is added as a new version in the price history within the same transaction.
+func (r *merchantRepository) UpdateMerchantItem(ctx context.Context, item model.MerchantItem, newPrice *model.ItemPrice) (err error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	res, err := tx.ExecContext(ctx, updateMerchantItemQuery, item.MerchantId, item.ID, item.Name, item.Category, item.ImageURL, item.IsAvailable, item.PrepTimeMinutes)
+	if err != nil {
+		return err
+	}
+	if err = expectAffected(res); err != nil {
+		return err
+	}
+	if newPrice != nil {
+		_, err = tx.ExecContext(ctx, insertItemPriceQuery, newPrice.ID, newPrice.ItemId, newPrice.Price, newPrice.EffectiveFrom, newPrice.EffectiveTo, newPrice.CreatedBy, newPrice.CreatedAt)
+	}
+	return err
+}
+
+// DeleteMerchantItem soft delete the item, order history keep its own item snapshot
+func (r *merchantRepository) DeleteMerchantItem(ctx context.Context, merchantId, itemId uuid.UUID, deletedAt time.Time) error {
+	res, err := r.db.ExecContext(ctx, deleteMerchantItemQuery, merchantId, itemId, deletedAt)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
 }
 
 func (r *merchantRepository) GetMerchantItem(ctx context.Context, params model.GetMerchantItemParams) (listMerchantItem []model.MerchantItem, meta model.MetaData, err error) {
 	listMerchantItem = []model.MerchantItem{}
-	var getMerchantItemQuery = `SELECT * FROM "merchantItem" WHERE true`
+	var getMerchantItemQuery = `SELECT ` + merchantItemColumns + ` FROM "merchantItem" WHERE "deletedAt" IS NULL`
 	var total int = 0
 	var metaData = model.MetaData{
 		Offset: params.Offset,
@@ -265,7 +478,7 @@ func (r *merchantRepository) GetMerchantItem(ctx context.Context, params model.G
 	// Iterate over the rows and scan each row into a struct
 	for rows.Next() {
 		var merchantItem model.MerchantItem
-		if err := rows.Scan(&merchantItem.ID, &merchantItem.MerchantId, &merchantItem.Name, &merchantItem.Category, &merchantItem.ImageURL, &merchantItem.Price, &merchantItem.CreatedAt); err != nil {
+		if err := rows.Scan(&merchantItem.ID, &merchantItem.MerchantId, &merchantItem.Name, &merchantItem.Category, &merchantItem.ImageURL, &merchantItem.Price, &merchantItem.PriceId, &merchantItem.IsAvailable, &merchantItem.CreatedAt, &merchantItem.PrepTimeMinutes); err != nil {
 			return listMerchantItem, metaData, err
 		}
 		listMerchantItem = append(listMerchantItem, merchantItem)
@@ -274,7 +487,7 @@ func (r *merchantRepository) GetMerchantItem(ctx context.Context, params model.G
 		return listMerchantItem, metaData, err
 	}
 
-	countQuery := strings.Replace(queryWithFilter, "SELECT * FROM", "SELECT count(id) FROM", 1)
+	countQuery := strings.Replace(queryWithFilter, "SELECT "+merchantItemColumns+" FROM", "SELECT count(id) FROM", 1)
 	err = r.db.QueryRowxContext(ctx, countQuery).Scan(&total)
 	if err != nil {
 		return listMerchantItem, metaData, err
@@ -286,3 +499,130 @@ func (r *merchantRepository) GetMerchantItem(ctx context.Context, params model.G
 
 	return listMerchantItem, metaData, nil
 }
+
+var (
+	getMerchantTimezoneQuery = `SELECT id, timezone FROM "merchant" WHERE "deletedAt" IS NULL AND id = ANY($1::uuid[])`
+	getOpeningHoursQuery     = `
+	SELECT "merchantId", "dayOfWeek", to_char("openTime", 'HH24:MI') AS "openTime", to_char("closeTime", 'HH24:MI') AS "closeTime"
+	FROM "merchantOpeningHours" WHERE "merchantId" = ANY($1::uuid[])
+	ORDER BY "dayOfWeek", "openTime";
+`
+	getScheduleOverridesQuery = `
+	SELECT "merchantId", to_char("date", 'YYYY-MM-DD') AS "date", "isClosed",
+	       to_char("openTime", 'HH24:MI') AS "openTime", to_char("closeTime", 'HH24:MI') AS "closeTime"
+	FROM "merchantScheduleOverride" WHERE "merchantId" = ANY($1::uuid[])
+	ORDER BY "date";
+`
+	updateMerchantTimezoneQuery = `UPDATE "merchant" SET timezone = $2 WHERE id = $1 AND "deletedAt" IS NULL`
+	deleteOpeningHoursQuery     = `DELETE FROM "merchantOpeningHours" WHERE "merchantId" = $1`
+	insertOpeningHourQuery      = `
+	INSERT INTO "merchantOpeningHours" ("merchantId", "dayOfWeek", "openTime", "closeTime")
+	VALUES ($1, $2, $3, $4);
+`
+	deleteScheduleOverridesQuery = `DELETE FROM "merchantScheduleOverride" WHERE "merchantId" = $1`
+	insertScheduleOverrideQuery  = `
+	INSERT INTO "merchantScheduleOverride" ("merchantId", "date", "isClosed", "openTime", "closeTime")
+	VALUES ($1, $2, $3, $4, $5);
+`
+)
+
+// GetMerchantScheduleMapByIds returns the opening hours and overrides of the active merchants
+func (r *merchantRepository) GetMerchantScheduleMapByIds(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]model.MerchantSchedule, error) {
+	schedules := make(map[uuid.UUID]model.MerchantSchedule)
+	merchantIds := uuidArray(ids)
+
+	rows, err := r.db.QueryxContext(ctx, getMerchantTimezoneQuery, merchantIds)
+	if err != nil {
+		return schedules, err
+	}
+	defer func(rows *sqlx.Rows) {
+		_ = rows.Close()
+	}(rows)
+	for rows.Next() {
+		var schedule model.MerchantSchedule
+		if err := rows.Scan(&schedule.MerchantId, &schedule.Timezone); err != nil {
+			return schedules, err
+		}
+		schedules[schedule.MerchantId] = schedule
+	}
+	if err := rows.Err(); err != nil {
+		return schedules, err
+	}
+
+	var hours []struct {
+		MerchantId uuid.UUID `db:"merchantId"`
+		model.OpeningHour
+	}
+	if err := r.db.SelectContext(ctx, &hours, getOpeningHoursQuery, merchantIds); err != nil {
+		return schedules, err
+	}
+	for _, h := range hours {
+		schedule, ok := schedules[h.MerchantId]
+		if !ok {
+			continue
+		}
+		schedule.OpeningHours = append(schedule.OpeningHours, h.OpeningHour)
+		schedules[h.MerchantId] = schedule
+	}
+
+	var overrides []struct {
+		MerchantId uuid.UUID `db:"merchantId"`
+		model.ScheduleOverride
+	}
+	if err := r.db.SelectContext(ctx, &overrides, getScheduleOverridesQuery, merchantIds); err != nil {
+		return schedules, err
+	}
+	for _, o := range overrides {
+		schedule, ok := schedules[o.MerchantId]
+		if !ok {
+			continue
+		}
+		schedule.Overrides = append(schedule.Overrides, o.ScheduleOverride)
+		schedules[o.MerchantId] = schedule
+	}
+
+	return schedules, nil
+}
+
+// ReplaceMerchantSchedule overwrite the timezone, the weekly opening hours and the overrides of the merchant
+func (r *merchantRepository) ReplaceMerchantSchedule(ctx context.Context, schedule model.MerchantSchedule) (err error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	res, err := tx.ExecContext(ctx, updateMerchantTimezoneQuery, schedule.MerchantId, schedule.Timezone)
+	if err != nil {
+		return err
+	}
+	if err = expectAffected(res); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, deleteOpeningHoursQuery, schedule.MerchantId); err != nil {
+		return err
+	}
+	for _, h := range schedule.OpeningHours {
+		if _, err = tx.ExecContext(ctx, insertOpeningHourQuery, schedule.MerchantId, h.DayOfWeek, h.OpenTime, h.CloseTime); err != nil {
+			return err
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, deleteScheduleOverridesQuery, schedule.MerchantId); err != nil {
+		return err
+	}
+	for _, o := range schedule.Overrides {
+		if _, err = tx.ExecContext(ctx, insertScheduleOverrideQuery, schedule.MerchantId, o.Date, o.IsClosed, o.OpenTime, o.CloseTime); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -16,7 +17,20 @@ type OrderRepository interface {
 	Create(ctx context.Context, tx *sqlx.Tx, order model.Order) (model.Order, error)
 	InsertCalculation(ctx context.Context, tx *sqlx.Tx, oc model.CalculatedEstimate) (model.CalculatedEstimate, error)
 	GetCalculatedEstimateById(ctx context.Context, id uuid.UUID) (model.CalculatedEstimate, error)
-	UpdateStatus(ctx context.Context, orderID uuid.UUID, status model.OrderStatus) error
+	GetCalculatedEstimateByIdForUpdate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (model.CalculatedEstimate, error)
+	ConfirmCalculatedEstimate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, confirmedAt time.Time) error
+	GetCalculatedEstimateByOrderId(ctx context.Context, orderID uuid.UUID) (model.CalculatedEstimate, error)
+	GetCalculatedEstimateByOrderIdForUpdate(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) (model.CalculatedEstimate, error)
+	InsertRefundLedger(ctx context.Context, tx *sqlx.Tx, refund model.RefundLedger) error
+	UpdateStatus(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID, from, to model.OrderStatus) error
+	InsertStatusHistory(ctx context.Context, tx *sqlx.Tx, history model.OrderStatusHistory) error
+	GetOrderById(ctx context.Context, orderID uuid.UUID) (model.Order, error)
+	GetOrderStatusHistory(ctx context.Context, orderID uuid.UUID, afterSeq int64) ([]model.OrderStatusHistory, error)
+	GetOrderByIdForUpdate(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) (model.Order, error)
+	UpdateDetail(ctx context.Context, tx *sqlx.Tx, order model.Order) error
+	UpdateCalculation(ctx context.Context, tx *sqlx.Tx, oc model.CalculatedEstimate) error
+	GetMerchantOrders(ctx context.Context, params model.MerchantOrdersParams) ([]model.Order, error)
+	DeleteDraftOrders(ctx context.Context, createdBefore time.Time, limit int) (int64, error)
 	GetUserOrders(ctx context.Context, params model.UserOrdersParams) ([]model.Order, error)
 	GetNearbyMerchant(ctx context.Context, params model.GetMerchantParams, lat, long string) (listNearbyMerchant []model.GetNearbyMerchantData, meta model.MetaData, err error)
 }
@@ -76,23 +90,172 @@ func (r *orderRepository) InsertCalculation(ctx context.Context, tx *sqlx.Tx, oc
 		"totalPrice",
 		"estimatedDeliveryTimeInMinutes",
 		"orderId",
-		"createdAt")
-	VALUES($1, $2, $3, $4, $5);
+		"createdAt",
+		"userId",
+		"expiresAt",
+		"subtotalPrice",
+		"promoCodeId",
+		"discountAmount",
+		"discountBreakdown",
+		"deliveryFee",
+		"extraMerchantFee",
+		"smallOrderFee",
+		"routeDistanceInKm",
+		"visitOrder",
+		"preparationTimeInMinutes",
+		"travelTimeInMinutes")
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18);
 	`
 	_, err := tx.ExecContext(ctx, insertCalculationQuery,
 		oc.CalculatedEstimateId,
 		oc.TotalPrice,
 		oc.EstimatedDeliveryTimeInMinutes,
 		oc.OrderId,
-		oc.CreatedAt)
+		oc.CreatedAt,
+		oc.UserId,
+		oc.ExpiresAt,
+		oc.SubtotalPrice,
+		oc.PromoCodeId,
+		oc.DiscountAmount,
+		oc.DiscountBreakdownRaw,
+		oc.DeliveryFee,
+		oc.ExtraMerchantFee,
+		oc.SmallOrderFee,
+		oc.RouteDistanceInKm,
+		oc.VisitOrder,
+		oc.PreparationTimeInMinutes,
+		oc.TravelTimeInMinutes)
 	return oc, err
 }
-func (r *orderRepository) UpdateStatus(ctx context.Context, orderID uuid.UUID, status model.OrderStatus) error {
-	var updateOrderStatus = `UPDATE "order" SET "orderStatus"=$2 WHERE "orderId"=$1`
-	_, err := r.db.ExecContext(ctx, updateOrderStatus, orderID, status)
+
+// UpdateStatus moves the order from one status to another, it returns sql.ErrNoRows
+// when the order is no longer in the `from` status (e.g. changed by another request)
+func (r *orderRepository) UpdateStatus(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID, from, to model.OrderStatus) error {
+	var updateOrderStatus = `UPDATE "order" SET "orderStatus"=$3 WHERE "orderId"=$1 AND "orderStatus"=$2`
+	res, err := tx.ExecContext(ctx, updateOrderStatus, orderID, from, to)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+func (r *orderRepository) InsertStatusHistory(ctx context.Context, tx *sqlx.Tx, history model.OrderStatusHistory) error {
+	var insertStatusHistoryQuery = `INSERT INTO "orderStatusHistory"(
+		id,
+		"orderId",
+		"fromStatus",
+		"toStatus",
+		"changedBy",
+		"createdAt")
+	VALUES($1, $2, $3, $4, $5, $6);
+	`
+	_, err := tx.ExecContext(ctx, insertStatusHistoryQuery,
+		history.ID,
+		history.OrderID,
+		history.FromStatus,
+		history.ToStatus,
+		history.ChangedBy,
+		history.CreatedAt)
+	return err
+}
+
+func (r *orderRepository) GetOrderById(ctx context.Context, orderID uuid.UUID) (model.Order, error) {
+	var getOrderByIdQuery = `SELECT * FROM "order" WHERE "orderId" = $1`
+	var order model.Order
+	err := r.db.QueryRowxContext(ctx, getOrderByIdQuery, orderID).StructScan(&order)
+	if err != nil {
+		return order, err
+	}
+	_ = json.Unmarshal(order.DetailRaw, &order.Detail)
+	return order, nil
+}
+
+// GetOrderByIdForUpdate lock the order row until the transaction end
+func (r *orderRepository) GetOrderByIdForUpdate(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) (model.Order, error) {
+	var getOrderByIdQuery = `SELECT * FROM "order" WHERE "orderId" = $1 FOR UPDATE`
+	var order model.Order
+	err := tx.QueryRowxContext(ctx, getOrderByIdQuery, orderID).StructScan(&order)
+	if err != nil {
+		return order, err
+	}
+	_ = json.Unmarshal(order.DetailRaw, &order.Detail)
+	return order, nil
+}
+
+func (r *orderRepository) UpdateDetail(ctx context.Context, tx *sqlx.Tx, order model.Order) error {
+	var updateOrderDetailQuery = `UPDATE "order" SET detail=$2 WHERE "orderId"=$1`
+	_, err := tx.ExecContext(ctx, updateOrderDetailQuery, order.OrderID, order.DetailRaw)
 	return err
 }
 
+func (r *orderRepository) UpdateCalculation(ctx context.Context, tx *sqlx.Tx, oc model.CalculatedEstimate) error {
+	var updateCalculationQuery = `UPDATE "calculatedEstimate" SET "totalPrice"=$2, "estimatedDeliveryTimeInMinutes"=$3, "subtotalPrice"=$4, "discountAmount"=$5,
+		"deliveryFee"=$6, "extraMerchantFee"=$7, "smallOrderFee"=$8, "routeDistanceInKm"=$9, "visitOrder"=$10,
+		"preparationTimeInMinutes"=$11, "travelTimeInMinutes"=$12, "discountBreakdown"=$13 WHERE "orderId"=$1`
+	_, err := tx.ExecContext(ctx, updateCalculationQuery, oc.OrderId, oc.TotalPrice, oc.EstimatedDeliveryTimeInMinutes, oc.SubtotalPrice, oc.DiscountAmount,
+		oc.DeliveryFee, oc.ExtraMerchantFee, oc.SmallOrderFee, oc.RouteDistanceInKm, oc.VisitOrder,
+		oc.PreparationTimeInMinutes, oc.TravelTimeInMinutes, oc.DiscountBreakdownRaw)
+	return err
+}
+
+func (r *orderRepository) GetMerchantOrders(ctx context.Context, params model.MerchantOrdersParams) ([]model.Order, error) {
+	listOrder := []model.Order{}
+	args := []interface{}{params.MerchantId.String()}
+	// use array containment so the query can hit the GIN index of merchantIds
+	var getMerchantOrdersQuery = `SELECT * FROM "order" WHERE "merchantIds" @> ARRAY[$1]::uuid[] `
+	if params.Status != "" {
+		args = append(args, params.Status)
+		getMerchantOrdersQuery += `AND "orderStatus" = $2 `
+	} else {
+		getMerchantOrdersQuery += fmt.Sprintf(`AND "orderStatus" <> '%s' `, model.OrderStatusDraft)
+	}
+
+	getMerchantOrdersQuery += fmt.Sprintf(`ORDER BY "createdAt" DESC LIMIT %d OFFSET %d`, params.Limit, params.Offset)
+	rows, err := r.db.QueryxContext(ctx, getMerchantOrdersQuery, args...)
+	if err != nil {
+		return listOrder, err
+	}
+	defer func(rows *sqlx.Rows) {
+		_ = rows.Close()
+	}(rows)
+	for rows.Next() {
+		var order model.Order
+		var detail model.OrderDetail
+		if err := rows.StructScan(&order); err != nil {
+			return listOrder, err
+		}
+		_ = json.Unmarshal(order.DetailRaw, &detail)
+		order.Detail = detail
+		listOrder = append(listOrder, order)
+	}
+	return listOrder, rows.Err()
+}
+
+// DeleteDraftOrders delete at most `limit` draft orders created before the given time,
+// the calculated estimate is deleted by cascade. locked rows are skipped so it never blocks a confirmation.
+func (r *orderRepository) DeleteDraftOrders(ctx context.Context, createdBefore time.Time, limit int) (int64, error) {
+	var deleteDraftOrdersQuery = `DELETE FROM "order" WHERE "orderId" IN (
+		SELECT "orderId" FROM "order"
+		WHERE "orderStatus" = $1 AND "createdAt" < $2
+		ORDER BY "createdAt" ASC
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED
+	)`
+	res, err := r.db.ExecContext(ctx, deleteDraftOrdersQuery, model.OrderStatusDraft, createdBefore, limit)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// GetOrderStatusHistory returns the status history with seq greater than afterSeq, use 0 to get all of them
+func (r *orderRepository) GetOrderStatusHistory(ctx context.Context, orderID uuid.UUID, afterSeq int64) ([]model.OrderStatusHistory, error) {
+	listHistory := []model.OrderStatusHistory{}
+	var getOrderStatusHistoryQuery = `SELECT * FROM "orderStatusHistory" WHERE "orderId" = $1 AND "seq" > $2 ORDER BY "seq" ASC`
+	err := r.db.SelectContext(ctx, &listHistory, getOrderStatusHistoryQuery, orderID, afterSeq)
+	return listHistory, err
+}
+
 func (r *orderRepository) GetCalculatedEstimateById(ctx context.Context, id uuid.UUID) (model.CalculatedEstimate, error) {
 	var getCalculatedEstimateByIdQuery = `SELECT * FROM "calculatedEstimate" WHERE "calculatedEstimateId" = $1`
 	var result model.CalculatedEstimate
@@ -100,11 +263,79 @@ func (r *orderRepository) GetCalculatedEstimateById(ctx context.Context, id uuid
 	return result, err
 }
 
+// GetCalculatedEstimateByIdForUpdate lock the estimate row so it can only be confirmed once
+func (r *orderRepository) GetCalculatedEstimateByIdForUpdate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (model.CalculatedEstimate, error) {
+	var getCalculatedEstimateByIdQuery = `SELECT * FROM "calculatedEstimate" WHERE "calculatedEstimateId" = $1 FOR UPDATE`
+	var result model.CalculatedEstimate
+	err := tx.QueryRowxContext(ctx, getCalculatedEstimateByIdQuery, id).StructScan(&result)
+	return result, err
+}
+
+// ConfirmCalculatedEstimate mark the estimate as used, it returns sql.ErrNoRows
+// when the estimate is already confirmed
+func (r *orderRepository) ConfirmCalculatedEstimate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, confirmedAt time.Time) error {
+	var confirmCalculatedEstimateQuery = `UPDATE "calculatedEstimate" SET "confirmedAt"=$2 WHERE "calculatedEstimateId"=$1 AND "confirmedAt" IS NULL`
+	res, err := tx.ExecContext(ctx, confirmCalculatedEstimateQuery, id, confirmedAt)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+func (r *orderRepository) GetCalculatedEstimateByOrderId(ctx context.Context, orderID uuid.UUID) (model.CalculatedEstimate, error) {
+	var getCalculatedEstimateByOrderIdQuery = `SELECT * FROM "calculatedEstimate" WHERE "orderId" = $1`
+	var result model.CalculatedEstimate
+	err := r.db.QueryRowxContext(ctx, getCalculatedEstimateByOrderIdQuery, orderID).StructScan(&result)
+	return result, err
+}
+
+// GetCalculatedEstimateByOrderIdForUpdate lock the estimate row of the order until the transaction end
+func (r *orderRepository) GetCalculatedEstimateByOrderIdForUpdate(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID) (model.CalculatedEstimate, error) {
+	var getCalculatedEstimateByOrderIdQuery = `SELECT * FROM "calculatedEstimate" WHERE "orderId" = $1 FOR UPDATE`
+	var result model.CalculatedEstimate
+	err := tx.QueryRowxContext(ctx, getCalculatedEstimateByOrderIdQuery, orderID).StructScan(&result)
+	return result, err
+}
+
+func (r *orderRepository) InsertRefundLedger(ctx context.Context, tx *sqlx.Tx, refund model.RefundLedger) error {
+	var insertRefundLedgerQuery = `INSERT INTO "refundLedger"(
+		id,
+		"orderId",
+		"calculatedEstimateId",
+		amount,
+		"reasonCode",
+		note,
+		initiator,
+		"initiatedBy",
+		"createdAt")
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9);
+	`
+	_, err := tx.ExecContext(ctx, insertRefundLedgerQuery,
+		refund.ID,
+		refund.OrderID,
+		refund.CalculatedEstimateId,
+		refund.Amount,
+		refund.ReasonCode,
+		refund.Note,
+		refund.Initiator,
+		refund.InitiatedBy,
+		refund.CreatedAt)
+	return err
+}
+
 func (r *orderRepository) GetUserOrders(ctx context.Context, params model.UserOrdersParams) ([]model.Order, error) {
 	listOrder := []model.Order{}
-	var getUserOrdersQuery = `SELECT * FROM "order" WHERE "userId" = $1 AND "orderStatus" = $2 AND TRUE `
+	args := []interface{}{params.UserID}
+	var getUserOrdersQuery = `SELECT * FROM "order" WHERE "userId" = $1 AND TRUE `
+	if params.Status != "" {
+		args = append(args, params.Status)
+		getUserOrdersQuery += `AND "orderStatus" = $2 `
+	} else {
+		// draft is only an estimation, not yet an order
+		getUserOrdersQuery += fmt.Sprintf(`AND "orderStatus" <> '%s' `, model.OrderStatusDraft)
+	}
 	if params.MerchantId != nil {
-		getUserOrdersQuery += fmt.Sprintf(`AND '%s'=ANY("merchantId")`, params.MerchantId.String())
+		getUserOrdersQuery += fmt.Sprintf(`AND "merchantIds" @> ARRAY['%s']::uuid[] `, params.MerchantId.String())
 	}
 	if params.Name != nil {
 		// query with searchable index
@@ -118,7 +349,7 @@ func (r *orderRepository) GetUserOrders(ctx context.Context, params model.UserOr
 	}
 
 	getUserOrdersQuery += fmt.Sprintf(`ORDER BY "createdAt" DESC LIMIT %d OFFSET %d`, params.Limit, params.Offset)
-	rows, err := r.db.QueryxContext(ctx, getUserOrdersQuery, params.UserID, params.Status)
+	rows, err := r.db.QueryxContext(ctx, getUserOrdersQuery, args...)
 	if err != nil {
 		return listOrder, err
 	}
@@ -141,7 +372,9 @@ func (r *orderRepository) GetUserOrders(ctx context.Context, params model.UserOr
 func (r *orderRepository) GetNearbyMerchant(ctx context.Context, params model.GetMerchantParams, lat, long string) (listNearbyMerchant []model.GetNearbyMerchantData, meta model.MetaData, err error) {
 	floatLat, _ := strconv.ParseFloat(lat, 64)
 	floatLong, _ := strconv.ParseFloat(long, 64)
-	var getMerchantQuery = fmt.Sprintf(`SELECT *, earth_distance(ll_to_earth(latitude, longitude), ll_to_earth('%f', '%f')) AS distance FROM "merchant" WHERE true`, floatLat, floatLong)
+	var distanceExpr = fmt.Sprintf(`earth_distance(ll_to_earth(latitude, longitude), ll_to_earth('%f', '%f'))`, floatLat, floatLong)
+	var getMerchantQuery = `SELECT "id", "name", "category", "imageUrl", "latitude", "longitude", "createdAt", "deliveryRadiusKm", ` + distanceExpr + ` AS distance,
+	COUNT(*) OVER() AS total FROM "merchant" WHERE "deletedAt" IS NULL`
 	var total int = 0
 	var metaData = model.MetaData{
 		Offset: params.Offset,
@@ -162,6 +395,16 @@ func (r *orderRepository) GetNearbyMerchant(ctx context.Context, params model.Ge
 		getMerchantQuery += fmt.Sprintf(` AND "category" = '%s'`, params.MerchantCategory)
 	}
 
+	// only the merchants delivering to the location, the distance is in meters
+	if params.DefaultDeliveryRadiusKm > 0 {
+		getMerchantQuery += fmt.Sprintf(` AND %s <= COALESCE("deliveryRadiusKm", %f) * 1000`, distanceExpr, params.DefaultDeliveryRadiusKm)
+	}
+
+	// only the merchants in the service area of the location, filtered before the paging
+	if params.ServiceArea != nil {
+		getMerchantQuery += ` AND ` + serviceAreaCondition(params.ServiceArea)
+	}
+
 	orderClause := ""
 	if params.CreatedAt != "" {
 		if params.CreatedAt != "desc" && params.CreatedAt != "asc" {
@@ -197,10 +440,13 @@ func (r *orderRepository) GetNearbyMerchant(ctx context.Context, params model.Ge
 		var items []model.Item
 		var nearbyMerchant model.GetNearbyMerchantData
 		var distance float64
-		if err := rows.Scan(&merchant.ID, &merchant.Name, &merchant.Category, &merchant.ImageURL, &merchant.Location.Lat, &merchant.Location.Long, &merchant.CreatedAt, &distance); err != nil {
+		if err := rows.Scan(&merchant.ID, &merchant.Name, &merchant.Category, &merchant.ImageURL, &merchant.Location.Lat, &merchant.Location.Long, &merchant.CreatedAt, &merchant.DeliveryRadiusKm, &distance, &total); err != nil {
 			return nil, metaData, err
 		}
-		var getItemById = `SELECT * FROM "merchantItem" WHERE "merchantId" = $1`
+		var getItemById = `SELECT ` + merchantItemColumns + ` FROM "merchantItem" WHERE "merchantId" = $1 AND "deletedAt" IS NULL`
+		if params.AvailableOnly {
+			getItemById += ` AND "isAvailable"`
+		}
 		rowsItem, err := r.db.QueryContext(ctx, getItemById, merchant.ID)
 		if err != nil {
 			return nil, metaData, err
@@ -211,7 +457,7 @@ func (r *orderRepository) GetNearbyMerchant(ctx context.Context, params model.Ge
 		items = []model.Item{}
 		for rowsItem.Next() {
 			var item model.Item
-			if err := rowsItem.Scan(&item.Id, &item.MerchantId, &item.Name, &item.Category, &item.ImageUrl, &item.Price, &item.CreatedAt); err != nil {
+			if err := rowsItem.Scan(&item.Id, &item.MerchantId, &item.Name, &item.Category, &item.ImageUrl, &item.Price, &item.PriceId, &item.IsAvailable, &item.CreatedAt, &item.PrepTimeMinutes); err != nil {
 				return nil, metaData, err
 			}
 
@@ -221,7 +467,6 @@ func (r *orderRepository) GetNearbyMerchant(ctx context.Context, params model.Ge
 		nearbyMerchant.Merchant = merchant
 		nearbyMerchant.Items = items
 		nearbyMerchant.Distance = fmt.Sprintf("%.2f m", distance)
-		total += 1
 		listNearbyMerchant = append(listNearbyMerchant, nearbyMerchant)
 	}
 	if err := rows.Err(); err != nil {
@@ -0,0 +1,130 @@
+package repo
+
+import (
+	"beli-mang/model"
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type PromoRepository interface {
+	CreatePromoCode(ctx context.Context, promo model.PromoCode) error
+	GetPromoCodes(ctx context.Context, params model.PromoCodeParams) ([]model.PromoCode, error)
+	GetPromoCodeByCode(ctx context.Context, code string) (model.PromoCode, error)
+	GetPromoCodeById(ctx context.Context, id uuid.UUID) (model.PromoCode, error)
+	GetPromoCodeByIdForUpdate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (model.PromoCode, error)
+	CountUserUsage(ctx context.Context, q sqlx.QueryerContext, promoCodeId, userId uuid.UUID) (int, error)
+	InsertUsage(ctx context.Context, tx *sqlx.Tx, usage model.PromoCodeUsage) error
+}
+
+type promoRepository struct {
+	db *sqlx.DB
+}
+
+func NewPromoRepository(db *sqlx.DB) PromoRepository {
+	return &promoRepository{db: db}
+}
+
+var (
+	promoCodeColumns = `id, code, "discountType", "discountValue", "maxDiscount", "minSpend", "perUserLimit", "usageLimit", "usedCount",
+	"validFrom", "validTo", "merchantIds", "merchantCategories", "itemCategories", "createdBy", "createdAt"`
+
+	createPromoCodeQuery = `
+	INSERT INTO "promoCode" (` + promoCodeColumns + `)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 0, $9, $10, $11, $12, $13, $14, $15);
+`
+	getPromoCodesQuery = `
+	SELECT ` + promoCodeColumns + ` FROM "promoCode"
+	WHERE ($1 = '' OR code LIKE '%' || $1 || '%')
+	ORDER BY "createdAt" DESC
+	LIMIT $2 OFFSET $3;
+`
+	getPromoCodeByCodeQuery          = `SELECT ` + promoCodeColumns + ` FROM "promoCode" WHERE code = $1`
+	getPromoCodeByIdQuery            = `SELECT ` + promoCodeColumns + ` FROM "promoCode" WHERE id = $1`
+	getPromoCodeByIdForUpdateQuery   = `SELECT ` + promoCodeColumns + ` FROM "promoCode" WHERE id = $1 FOR UPDATE`
+	countPromoCodeUserUsageQuery     = `SELECT COUNT(*) FROM "promoCodeUsage" WHERE "promoCodeId" = $1 AND "userId" = $2`
+	incrementPromoCodeUsedCountQuery = `UPDATE "promoCode" SET "usedCount" = "usedCount" + 1 WHERE id = $1`
+	insertPromoCodeUsageQuery        = `
+	INSERT INTO "promoCodeUsage" (id, "promoCodeId", "userId", "orderId", "calculatedEstimateId", discount, "createdAt")
+	VALUES ($1, $2, $3, $4, $5, $6, $7);
+`
+)
+
+func (r *promoRepository) CreatePromoCode(ctx context.Context, promo model.PromoCode) error {
+	_, err := r.db.ExecContext(ctx, createPromoCodeQuery,
+		promo.ID,
+		promo.Code,
+		promo.DiscountType,
+		promo.DiscountValue,
+		promo.MaxDiscount,
+		promo.MinSpend,
+		promo.PerUserLimit,
+		promo.UsageLimit,
+		promo.ValidFrom,
+		promo.ValidTo,
+		promo.MerchantIds,
+		promo.MerchantCategories,
+		promo.ItemCategories,
+		promo.CreatedBy,
+		promo.CreatedAt,
+	)
+	return err
+}
+
+func (r *promoRepository) GetPromoCodes(ctx context.Context, params model.PromoCodeParams) ([]model.PromoCode, error) {
+	promos := []model.PromoCode{}
+	err := r.db.SelectContext(ctx, &promos, getPromoCodesQuery, params.Code, params.Limit, params.Offset)
+	return promos, err
+}
+
+func (r *promoRepository) GetPromoCodeByCode(ctx context.Context, code string) (model.PromoCode, error) {
+	var promo model.PromoCode
+	err := r.db.GetContext(ctx, &promo, getPromoCodeByCodeQuery, code)
+	return promo, err
+}
+
+func (r *promoRepository) GetPromoCodeById(ctx context.Context, id uuid.UUID) (model.PromoCode, error) {
+	var promo model.PromoCode
+	err := r.db.GetContext(ctx, &promo, getPromoCodeByIdQuery, id)
+	return promo, err
+}
+
+// GetPromoCodeByIdForUpdate lock the promo so the usage limit is checked and counted by one order at a time
+func (r *promoRepository) GetPromoCodeByIdForUpdate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (model.PromoCode, error) {
+	var promo model.PromoCode
+	err := tx.GetContext(ctx, &promo, getPromoCodeByIdForUpdateQuery, id)
+	return promo, err
+}
+
+// CountUserUsage returns how many confirmed orders of the user used the promo,
+// pass the tx to count inside it or the db otherwise
+func (r *promoRepository) CountUserUsage(ctx context.Context, q sqlx.QueryerContext, promoCodeId, userId uuid.UUID) (int, error) {
+	if q == nil {
+		q = r.db
+	}
+	var count int
+	err := sqlx.GetContext(ctx, q, &count, countPromoCodeUserUsageQuery, promoCodeId, userId)
+	return count, err
+}
+
+// InsertUsage record the usage and count it on the promo
+func (r *promoRepository) InsertUsage(ctx context.Context, tx *sqlx.Tx, usage model.PromoCodeUsage) error {
+	_, err := tx.ExecContext(ctx, insertPromoCodeUsageQuery,
+		usage.ID,
+		usage.PromoCodeId,
+		usage.UserId,
+		usage.OrderId,
+		usage.CalculatedEstimateId,
+		usage.Discount,
+		usage.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx, incrementPromoCodeUsedCountQuery, usage.PromoCodeId)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
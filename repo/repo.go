@@ -1,6 +1,12 @@
 package repo
 
-import "github.com/jmoiron/sqlx"
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
 
 type Repo interface{}
 
@@ -13,3 +19,24 @@ func NewRepo(db *sqlx.DB) Repo {
 		db: db,
 	}
 }
+
+// expectAffected returns sql.ErrNoRows when the statement doesn't change any row
+func expectAffected(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// uuidArray convert the ids into a postgres array, use it with ANY($1::uuid[])
+func uuidArray(ids []uuid.UUID) pq.StringArray {
+	arr := make(pq.StringArray, len(ids))
+	for i, id := range ids {
+		arr[i] = id.String()
+	}
+	return arr
+}
@@ -0,0 +1,105 @@
+package repo
+
+import (
+	"beli-mang/model"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type ServiceAreaRepository interface {
+	CreateServiceArea(ctx context.Context, area model.ServiceArea) error
+	GetServiceAreas(ctx context.Context) ([]model.ServiceArea, error)
+	GetServiceAreaByName(ctx context.Context, name string) (model.ServiceArea, error)
+	DeleteServiceArea(ctx context.Context, id uuid.UUID) error
+}
+
+type serviceAreaRepository struct {
+	db *sqlx.DB
+}
+
+func NewServiceAreaRepository(db *sqlx.DB) ServiceAreaRepository {
+	return &serviceAreaRepository{db: db}
+}
+
+var (
+	serviceAreaColumns = `id, name, geometry, "createdAt"`
+
+	createServiceAreaQuery    = `INSERT INTO "serviceArea" (` + serviceAreaColumns + `) VALUES ($1, $2, $3, $4)`
+	getServiceAreasQuery      = `SELECT ` + serviceAreaColumns + ` FROM "serviceArea" ORDER BY name`
+	getServiceAreaByNameQuery = `SELECT ` + serviceAreaColumns + ` FROM "serviceArea" WHERE name = $1`
+	deleteServiceAreaQuery    = `DELETE FROM "serviceArea" WHERE id = $1`
+)
+
+func (r *serviceAreaRepository) CreateServiceArea(ctx context.Context, area model.ServiceArea) error {
+	_, err := r.db.ExecContext(ctx, createServiceAreaQuery, area.ID, area.Name, area.Geometry, area.CreatedAt)
+	return err
+}
+
+func (r *serviceAreaRepository) GetServiceAreas(ctx context.Context) ([]model.ServiceArea, error) {
+	areas := []model.ServiceArea{}
+	err := r.db.SelectContext(ctx, &areas, getServiceAreasQuery)
+	return areas, err
+}
+
+func (r *serviceAreaRepository) GetServiceAreaByName(ctx context.Context, name string) (model.ServiceArea, error) {
+	var area model.ServiceArea
+	err := r.db.GetContext(ctx, &area, getServiceAreaByNameQuery, name)
+	return area, err
+}
+
+func (r *serviceAreaRepository) DeleteServiceArea(ctx context.Context, id uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, deleteServiceAreaQuery, id)
+	if err != nil {
+		return err
+	}
+	return expectAffected(res)
+}
+
+// serviceAreaCondition returns the sql condition of the merchant location being inside the area. The bounding
+// box is a cheap prefilter, then the built-in polygon type checks the outer rings and the holes.
+func serviceAreaCondition(area model.MultiPolygon) string {
+	var polygons []string
+	for _, polygon := range area {
+		if len(polygon) == 0 {
+			continue
+		}
+		minLong, minLat, maxLong, maxLat := ringBounds(polygon[0])
+		condition := fmt.Sprintf(`(latitude BETWEEN %s AND %s AND longitude BETWEEN %s AND %s AND %s @> point(longitude, latitude)`,
+			formatFloat(minLat), formatFloat(maxLat), formatFloat(minLong), formatFloat(maxLong), sqlPolygon(polygon[0]))
+		for _, hole := range polygon[1:] {
+			condition += fmt.Sprintf(` AND NOT %s @> point(longitude, latitude)`, sqlPolygon(hole))
+		}
+		polygons = append(polygons, condition+`)`)
+	}
+	if len(polygons) == 0 {
+		return `FALSE`
+	}
+	return `(` + strings.Join(polygons, ` OR `) + `)`
+}
+
+// sqlPolygon format the ring as a polygon literal of (longitude, latitude) points
+func sqlPolygon(ring [][]float64) string {
+	points := make([]string, 0, len(ring))
+	for _, position := range ring {
+		points = append(points, `(`+formatFloat(position[0])+`,`+formatFloat(position[1])+`)`)
+	}
+	return `polygon '(` + strings.Join(points, `,`) + `)'`
+}
+
+func ringBounds(ring [][]float64) (minLong, minLat, maxLong, maxLat float64) {
+	minLong, minLat, maxLong, maxLat = ring[0][0], ring[0][1], ring[0][0], ring[0][1]
+	for _, position := range ring[1:] {
+		minLong, maxLong = min(minLong, position[0]), max(maxLong, position[0])
+		minLat, maxLat = min(minLat, position[1]), max(maxLat, position[1])
+	}
+	return minLong, minLat, maxLong, maxLat
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -7,6 +7,7 @@ import (
 	"beli-mang/model"
 	"beli-mang/repo"
 	"beli-mang/service"
+	"expvar"
 	"net/http"
 
 	"github.com/go-playground/validator/v10"
@@ -24,11 +25,17 @@ func (s *Server) RegisterRoute(cfg *config.Config) {
 		})
 		return nil
 	})
+	// expvar metrics, e.g. draft reaper counter
+	superAdminAuth := middleware.Authentication(cfg.JWTSecret, model.RoleSuperAdmin)
+	mainRoute.GET("/debug/vars", superAdminAuth(echo.WrapHandler(expvar.Handler())))
 
 	registerImageRoute(mainRoute, cfg, s.logger)
-	registerMerchantRoute(mainRoute, s.db, cfg, s.validator)
+	registerMerchantRoute(mainRoute, s.db, cfg, s.validator, s.logger)
 	registerStaffRoute(mainRoute, s.db, cfg, s.validator)
 	registerPurchaseRoute(mainRoute, s.db, cfg, s.validator, s.logger)
+	registerPromoRoute(mainRoute, s.db, cfg, s.validator)
+	registerServiceAreaRoute(mainRoute, s.db, cfg, s.validator)
+	registerOrderEventRoute(mainRoute, cfg, s.orderEvents)
 }
 
 func registerImageRoute(e *echo.Echo, cfg *config.Config, logger *zap.Logger) {
@@ -39,24 +46,79 @@ func registerImageRoute(e *echo.Echo, cfg *config.Config, logger *zap.Logger) {
 	e.POST("/image", auth(ctr.PostImage))
 }
 
-func registerMerchantRoute(e *echo.Echo, db *sqlx.DB, cfg *config.Config, validate *validator.Validate) {
-	ctr := controller.NewMerchantController(service.NewMerchantService(repo.NewMerchantRepository(db)), validate)
+func registerMerchantRoute(e *echo.Echo, db *sqlx.DB, cfg *config.Config, validate *validator.Validate, logger *zap.Logger) {
+	merchantRepo := repo.NewMerchantRepository(db)
+	ctr := controller.NewMerchantController(service.NewMerchantService(merchantRepo), validate, logger)
 
 	auth := middleware.Authentication(cfg.JWTSecret, model.RoleAdmin)
+	merchantAccess := middleware.MerchantAccess(merchantRepo)
 	e.POST("/admin/merchants", auth(ctr.CreateMerchant))
 	e.GET("/admin/merchants", auth(ctr.GetMerchant))
-	e.POST("/admin/merchants/:merchantId/items", auth(ctr.CreateMerchantItem))
-	e.GET("/admin/merchants/:merchantId/items", auth(ctr.GetMerchantItem))
+	e.GET("/admin/catalog/export", auth(ctr.ExportCatalog))
+	e.PATCH("/admin/merchants/:merchantId", auth(merchantAccess(ctr.UpdateMerchant)))
+	e.DELETE("/admin/merchants/:merchantId", auth(merchantAccess(ctr.DeleteMerchant)))
+	e.POST("/admin/merchants/:merchantId/items", auth(merchantAccess(ctr.CreateMerchantItem)))
+	e.GET("/admin/merchants/:merchantId/items", auth(merchantAccess(ctr.GetMerchantItem)))
+	e.POST("/admin/merchants/:merchantId/items/import", auth(merchantAccess(ctr.ImportMerchantItems)))
+	e.PATCH("/admin/merchants/:merchantId/items/:itemId", auth(merchantAccess(ctr.UpdateMerchantItem)))
+	e.DELETE("/admin/merchants/:merchantId/items/:itemId", auth(merchantAccess(ctr.DeleteMerchantItem)))
+	e.GET("/admin/merchants/:merchantId/items/:itemId/prices", auth(merchantAccess(ctr.GetItemPriceTimeline)))
+	e.POST("/admin/merchants/:merchantId/items/:itemId/prices", auth(merchantAccess(ctr.ScheduleItemPrice)))
+	e.GET("/admin/merchants/:merchantId/items/:itemId/option-groups", auth(merchantAccess(ctr.GetItemOptionGroups)))
+	e.PUT("/admin/merchants/:merchantId/items/:itemId/option-groups", auth(merchantAccess(ctr.SetItemOptionGroups)))
+	e.GET("/admin/merchants/:merchantId/opening-hours", auth(merchantAccess(ctr.GetMerchantSchedule)))
+	e.PUT("/admin/merchants/:merchantId/opening-hours", auth(merchantAccess(ctr.UpdateMerchantSchedule)))
 }
 
 func registerPurchaseRoute(e *echo.Echo, db *sqlx.DB, cfg *config.Config, validate *validator.Validate, logger *zap.Logger) {
-	ctr := controller.NewPurchaseController(service.NewPurchaseService(repo.NewOrderRepository(db), repo.NewMerchantRepository(db), logger), validate)
+	merchantRepo := repo.NewMerchantRepository(db)
+	ctr := controller.NewPurchaseController(service.NewPurchaseService(cfg, repo.NewOrderRepository(db), merchantRepo, repo.NewPromoRepository(db), repo.NewServiceAreaRepository(db), service.NewRoutingProvider(cfg.Routing, logger), logger), validate)
 
 	auth := middleware.Authentication(cfg.JWTSecret, model.RoleAll)
+	idempotent := middleware.Idempotency(repo.NewIdempotencyRepository(db), cfg.IdempotencyTTL, cfg.IdempotencyLease, logger)
 	e.GET("/merchants/nearby/:latlong", auth(ctr.GetMerchantNearby))
-	e.POST("/users/estimate", auth(ctr.EstimateOrders))
-	e.POST("/users/orders", auth(ctr.ConfirmOrder))
+	e.POST("/users/estimate", auth(idempotent(ctr.EstimateOrders)))
+	e.POST("/users/orders", auth(idempotent(ctr.ConfirmOrder)))
 	e.GET("/users/orders", auth(ctr.GetUserOrders))
+	e.GET("/users/orders/:orderId", auth(ctr.GetOrderDetail))
+	e.POST("/users/orders/:orderId/cancel", auth(ctr.CancelUserOrder))
+	e.POST("/users/orders/:orderId/reorder", auth(idempotent(ctr.Reorder)))
+
+	adminAuth := middleware.Authentication(cfg.JWTSecret, model.RoleAdmin)
+	merchantAccess := middleware.MerchantAccess(merchantRepo)
+	e.PATCH("/admin/orders/:orderId/status", adminAuth(ctr.UpdateOrderStatus))
+	e.GET("/admin/merchants/:merchantId/orders", adminAuth(merchantAccess(ctr.GetMerchantOrders)))
+	e.POST("/admin/merchants/:merchantId/orders/:orderId/accept", adminAuth(merchantAccess(ctr.AcceptMerchantOrder)))
+	e.POST("/admin/merchants/:merchantId/orders/:orderId/reject", adminAuth(merchantAccess(ctr.RejectMerchantOrder)))
+	e.POST("/admin/merchants/:merchantId/orders/:orderId/cancel", adminAuth(merchantAccess(ctr.CancelMerchantOrder)))
+
+	superAdminAuth := middleware.Authentication(cfg.JWTSecret, model.RoleSuperAdmin)
+	e.POST("/admin/speed-profiles/preview", superAdminAuth(ctr.PreviewSpeedProfiles))
+}
+
+func registerPromoRoute(e *echo.Echo, db *sqlx.DB, cfg *config.Config, validate *validator.Validate) {
+	ctr := controller.NewPromoController(service.NewPromoService(repo.NewPromoRepository(db)), validate)
+
+	// promo codes apply across merchants, only super admin can manage them
+	auth := middleware.Authentication(cfg.JWTSecret, model.RoleSuperAdmin)
+	e.POST("/admin/promo-codes", auth(ctr.CreatePromoCode))
+	e.GET("/admin/promo-codes", auth(ctr.GetPromoCodes))
+}
+
+func registerServiceAreaRoute(e *echo.Echo, db *sqlx.DB, cfg *config.Config, validate *validator.Validate) {
+	ctr := controller.NewServiceAreaController(service.NewServiceAreaService(repo.NewServiceAreaRepository(db)), validate)
+
+	auth := middleware.Authentication(cfg.JWTSecret, model.RoleSuperAdmin)
+	e.POST("/admin/service-areas", auth(ctr.CreateServiceArea))
+	e.GET("/admin/service-areas", auth(ctr.GetServiceAreas))
+	e.DELETE("/admin/service-areas/:serviceAreaId", auth(ctr.DeleteServiceArea))
+}
+
+func registerOrderEventRoute(e *echo.Echo, cfg *config.Config, svc service.OrderEventService) {
+	ctr := controller.NewOrderEventController(svc)
+
+	auth := middleware.Authentication(cfg.JWTSecret, model.RoleAll)
+	e.GET("/users/orders/:orderId/events", auth(ctr.StreamOrderEvents))
 }
 
 func registerStaffRoute(e *echo.Echo, db *sqlx.DB, cfg *config.Config, validate *validator.Validate) {
@@ -1,6 +1,9 @@
 package server
 
 import (
+	"beli-mang/service"
+	"context"
+
 	"github.com/go-playground/validator/v10"
 	"github.com/jmoiron/sqlx"
 	"github.com/labstack/echo/v4"
@@ -9,13 +12,14 @@ import (
 )
 
 type Server struct {
-	db        *sqlx.DB
-	app       *echo.Echo
-	validator *validator.Validate
-	logger    *zap.Logger
+	db          *sqlx.DB
+	app         *echo.Echo
+	validator   *validator.Validate
+	logger      *zap.Logger
+	orderEvents service.OrderEventService
 }
 
-func NewServer(db *sqlx.DB, logger *zap.Logger) *Server {
+func NewServer(db *sqlx.DB, logger *zap.Logger, orderEvents service.OrderEventService) *Server {
 	app := echo.New()
 	validate := validator.New()
 
@@ -23,13 +27,18 @@ func NewServer(db *sqlx.DB, logger *zap.Logger) *Server {
 	app.Use(middleware.Logger())
 
 	return &Server{
-		db:        db,
-		app:       app,
-		validator: validate,
-		logger:    logger,
+		db:          db,
+		app:         app,
+		validator:   validate,
+		logger:      logger,
+		orderEvents: orderEvents,
 	}
 }
 
 func (s *Server) Start() error {
 	return s.app.Start(":8080")
 }
+
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.app.Shutdown(ctx)
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"beli-mang/config"
+	"beli-mang/repo"
+	"context"
+	"expvar"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// draft reaper metrics, exposed on /debug/vars
+var (
+	draftReaperRuns              = expvar.NewInt("draft_reaper_runs_total")
+	draftReaperErrors            = expvar.NewInt("draft_reaper_errors_total")
+	draftReaperDeleted           = expvar.NewInt("draft_reaper_deleted_total")
+	draftReaperLastDeleted       = expvar.NewInt("draft_reaper_last_run_deleted")
+	idempotencyReaperDeleted     = expvar.NewInt("idempotency_reaper_deleted_total")
+	idempotencyReaperLastDeleted = expvar.NewInt("idempotency_reaper_last_run_deleted")
+)
+
+// DraftOrderReaper periodically delete draft orders that are never confirmed,
+// and the idempotency keys that are expired together with their stored response
+type DraftOrderReaper struct {
+	orderRepo       repo.OrderRepository
+	idempotencyRepo repo.IdempotencyRepository
+	logger          *zap.Logger
+	interval        time.Duration
+	maxAge          time.Duration
+	batchSize       int
+}
+
+func NewDraftOrderReaper(cfg *config.Config, orderRepo repo.OrderRepository, idempotencyRepo repo.IdempotencyRepository, logger *zap.Logger) *DraftOrderReaper {
+	maxAge := cfg.DraftReaper.MaxAge
+	// never delete a draft that can still be confirmed
+	if maxAge < cfg.EstimateTTL {
+		maxAge = cfg.EstimateTTL
+	}
+
+	return &DraftOrderReaper{
+		orderRepo:       orderRepo,
+		idempotencyRepo: idempotencyRepo,
+		logger:          logger,
+		interval:        cfg.DraftReaper.Interval,
+		maxAge:          maxAge,
+		batchSize:       cfg.DraftReaper.BatchSize,
+	}
+}
+
+// Run blocks and reap draft orders every interval until ctx is done
+func (r *DraftOrderReaper) Run(ctx context.Context) {
+	if r.interval <= 0 || r.batchSize <= 0 {
+		r.logger.Info("[draftReaper] disabled")
+		return
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Info("[draftReaper] stopped")
+			return
+		case <-ticker.C:
+			r.reap(ctx)
+		}
+	}
+}
+
+// reap delete expired drafts and idempotency keys batch by batch, so each delete statement only hold a small number of locks
+func (r *DraftOrderReaper) reap(ctx context.Context) {
+	start := time.Now()
+	createdBefore := start.Add(-r.maxAge)
+
+	draftReaperRuns.Add(1)
+	drafts, err := r.deleteInBatches(ctx, draftReaperDeleted, func(ctx context.Context) (int64, error) {
+		return r.orderRepo.DeleteDraftOrders(ctx, createdBefore, r.batchSize)
+	})
+	if err != nil {
+		draftReaperErrors.Add(1)
+		r.logger.Error("[draftReaper] failed to delete draft orders", zap.Error(err))
+	}
+	draftReaperLastDeleted.Set(drafts)
+
+	keys, err := r.deleteInBatches(ctx, idempotencyReaperDeleted, func(ctx context.Context) (int64, error) {
+		return r.idempotencyRepo.DeleteExpired(ctx, start, r.batchSize)
+	})
+	if err != nil {
+		draftReaperErrors.Add(1)
+		r.logger.Error("[draftReaper] failed to delete expired idempotency keys", zap.Error(err))
+	}
+	idempotencyReaperLastDeleted.Set(keys)
+
+	r.logger.Info("[draftReaper] run finished",
+		zap.Int64("deleted", drafts),
+		zap.Int64("deletedIdempotencyKeys", keys),
+		zap.Duration("duration", time.Since(start)),
+	)
+}
+
+// deleteInBatches call deleteBatch until a batch isn't full, it returns the number of deleted rows.
+// the error of a stopping ctx isn't returned.
+func (r *DraftOrderReaper) deleteInBatches(ctx context.Context, counter *expvar.Int, deleteBatch func(ctx context.Context) (int64, error)) (int64, error) {
+	var total int64
+	for ctx.Err() == nil {
+		deleted, err := deleteBatch(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return total, nil
+			}
+			return total, err
+		}
+		total += deleted
+		counter.Add(deleted)
+		if deleted < int64(r.batchSize) {
+			break
+		}
+	}
+	return total, nil
+}
@@ -8,11 +8,11 @@ import (
 
 const (
 	EarthRadius    = 6371.0 // Earth radius in kilometers
-	SpeedKmPerHour = 40.0   // Speed in kilometers per hour
+	SpeedKmPerHour = 40.0   // Free flow speed in kilometers per hour
 )
 
-// EstimateDeliveryTimeMulti estimates the delivery time for a route with multiple waypoints
-func EstimateDeliveryTimeMulti(waypoints []model.Point) time.Duration {
+// routeDistance returns the length of the route through the waypoints in km
+func routeDistance(waypoints []model.Point) float64 {
 	totalDistance := 0.0
 
 	// Calculate distance and time between consecutive waypoints
@@ -24,7 +24,11 @@ func EstimateDeliveryTimeMulti(waypoints []model.Point) time.Duration {
 		distance := haversineDistance(start.Lat, start.Lon, end.Lat, end.Lon)
 		totalDistance += distance
 	}
+	return totalDistance
+}
 
+// travelTime converts the distance in km to the time it takes at SpeedKmPerHour
+func travelTime(totalDistance float64) time.Duration {
 	// Calculate estimated time in hours
 	estimatedTimeHours := totalDistance / SpeedKmPerHour
 
@@ -34,6 +38,23 @@ func EstimateDeliveryTimeMulti(waypoints []model.Point) time.Duration {
 	return estimatedTime
 }
 
+// courierWaitTime returns how long the courier waits for the food at the stops. Every merchant start
+// preparing when the order is made and the courier leaves a stop once its food is ready,
+// readyIn and legDurations are in the visiting order, legDurations[i] is the travel after stop i.
+func courierWaitTime(readyIn, legDurations []time.Duration) time.Duration {
+	var clock, wait time.Duration
+	for i, ready := range readyIn {
+		if ready > clock {
+			wait += ready - clock
+			clock = ready
+		}
+		if i < len(legDurations) {
+			clock += legDurations[i]
+		}
+	}
+	return wait
+}
+
 // haversineDistance calculates the distance between two points using the Haversine formula
 func haversineDistance(lat1, lon1, lat2, lon2 float64) float64 {
 	// Convert latitude and longitude from degrees to radians
@@ -58,55 +79,3 @@ func haversineDistance(lat1, lon1, lat2, lon2 float64) float64 {
 func degToRad(deg float64) float64 {
 	return deg * (math.Pi / 180.0)
 }
-
-// EstimateDeliveryTimeTSP estimates the delivery time for a route with multiple waypoints using TSP
-func EstimateDeliveryTimeTSP(merchantsPoint []model.Point, userPoint model.Point) time.Duration {
-	// Include start and end points in the list of waypoints
-	allWaypoints := append([]model.Point{}, merchantsPoint...)
-	allWaypoints = append(allWaypoints, userPoint)
-	if len(merchantsPoint) == 1 {
-		return EstimateDeliveryTimeMulti(allWaypoints)
-	}
-
-	// Find the shortest path using the nearest neighbor algorithm
-	shortestPath := nearestNeighbor(allWaypoints)
-
-	// Calculate estimated time for the shortest path
-	return EstimateDeliveryTimeMulti(shortestPath)
-}
-
-// nearestNeighbor finds the shortest path using the nearest neighbor algorithm
-func nearestNeighbor(waypoints []model.Point) []model.Point {
-	n := len(waypoints)
-	visited := make([]bool, n)
-	path := make([]model.Point, 0)
-
-	// Start from the first waypoint
-	current := waypoints[0]
-	visited[0] = true
-	path = append(path, current)
-
-	// Visit each waypoint using the nearest neighbor
-	for len(path) < n {
-		minDistance := math.Inf(1)
-		nearestIndex := -1
-
-		for i, point := range waypoints {
-			if !visited[i] {
-				distance := haversineDistance(current.Lat, current.Lon, point.Lat, point.Lon)
-				if distance < minDistance {
-					minDistance = distance
-					nearestIndex = i
-				}
-			}
-		}
-
-		if nearestIndex != -1 {
-			current = waypoints[nearestIndex]
-			visited[nearestIndex] = true
-			path = append(path, current)
-		}
-	}
-
-	return path
-}
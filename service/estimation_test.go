@@ -0,0 +1,56 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCourierWaitTime(t *testing.T) {
+	m := time.Minute
+	tests := []struct {
+		name         string
+		readyIn      []time.Duration
+		legDurations []time.Duration
+		want         time.Duration
+	}{
+		{name: "no stop", want: 0},
+		{name: "everything ready", readyIn: []time.Duration{0, 0}, legDurations: []time.Duration{5 * m, 5 * m}, want: 0},
+		{name: "wait at the start", readyIn: []time.Duration{10 * m}, legDurations: []time.Duration{5 * m}, want: 10 * m},
+		{
+			name:         "next stop ready before arrival",
+			readyIn:      []time.Duration{10 * m, 12 * m},
+			legDurations: []time.Duration{5 * m, 3 * m},
+			want:         10 * m,
+		},
+		{
+			name:         "wait at both stops",
+			readyIn:      []time.Duration{10 * m, 20 * m},
+			legDurations: []time.Duration{5 * m, 3 * m},
+			want:         15 * m,
+		},
+		{
+			name:         "ready exactly on arrival",
+			readyIn:      []time.Duration{10 * m, 15 * m},
+			legDurations: []time.Duration{5 * m, 3 * m},
+			want:         10 * m,
+		},
+		{
+			name:         "only the later stop is slow",
+			readyIn:      []time.Duration{0, 20 * m, 0},
+			legDurations: []time.Duration{5 * m, 5 * m, 5 * m},
+			want:         15 * m,
+		},
+		{
+			name:    "missing legs count as no travel",
+			readyIn: []time.Duration{5 * m, 10 * m},
+			want:    10 * m,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := courierWaitTime(tt.readyIn, tt.legDurations); got != tt.want {
+				t.Errorf("courierWaitTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
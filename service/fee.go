@@ -0,0 +1,23 @@
+package service
+
+import (
+	"beli-mang/config"
+	"beli-mang/model"
+	"math"
+)
+
+// calculateFees price the delivery of merchantCount merchants over a route of distanceKm,
+// subtotal is the items price used to decide the small order fee.
+// The delivery fee is scaled by the traffic fee multiplier, e.g. on the rush hours.
+func calculateFees(cfg config.FeeConfig, traffic model.Traffic, distanceKm float64, merchantCount, subtotal int) model.EstimateFees {
+	fees := model.EstimateFees{
+		DeliveryFee: int(math.Round((float64(cfg.Base) + cfg.PerKm*distanceKm) * traffic.FeeMultiplier)),
+	}
+	if merchantCount > 1 {
+		fees.ExtraMerchantFee = cfg.PerExtraMerchant * (merchantCount - 1)
+	}
+	if subtotal < cfg.SmallOrderThreshold {
+		fees.SmallOrderFee = cfg.SmallOrder
+	}
+	return fees
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"beli-mang/config"
+	"beli-mang/model"
+	"testing"
+)
+
+func TestCalculateFees(t *testing.T) {
+	cfg := config.FeeConfig{
+		Base:                5000,
+		PerKm:               2000,
+		PerExtraMerchant:    3000,
+		SmallOrder:          2000,
+		SmallOrderThreshold: 25000,
+	}
+	rush := model.Traffic{SpeedProfile: "rush", SpeedKmPerHour: 20, FeeMultiplier: 1.5}
+
+	tests := []struct {
+		name          string
+		traffic       model.Traffic
+		distanceKm    float64
+		merchantCount int
+		subtotal      int
+		want          model.EstimateFees
+	}{
+		{
+			name:          "single merchant",
+			traffic:       freeFlowTraffic,
+			distanceKm:    2.5,
+			merchantCount: 1,
+			subtotal:      30000,
+			want:          model.EstimateFees{DeliveryFee: 10000},
+		},
+		{
+			name:          "zero distance is the base fee",
+			traffic:       freeFlowTraffic,
+			merchantCount: 1,
+			subtotal:      30000,
+			want:          model.EstimateFees{DeliveryFee: 5000},
+		},
+		{
+			name:          "delivery fee rounded",
+			traffic:       freeFlowTraffic,
+			distanceKm:    1.00025,
+			merchantCount: 1,
+			subtotal:      30000,
+			want:          model.EstimateFees{DeliveryFee: 7001},
+		},
+		{
+			name:          "extra merchants",
+			traffic:       freeFlowTraffic,
+			distanceKm:    1,
+			merchantCount: 3,
+			subtotal:      30000,
+			want:          model.EstimateFees{DeliveryFee: 7000, ExtraMerchantFee: 6000},
+		},
+		{
+			name:          "small order below the threshold",
+			traffic:       freeFlowTraffic,
+			distanceKm:    1,
+			merchantCount: 1,
+			subtotal:      24999,
+			want:          model.EstimateFees{DeliveryFee: 7000, SmallOrderFee: 2000},
+		},
+		{
+			name:          "no small order fee at the threshold",
+			traffic:       freeFlowTraffic,
+			distanceKm:    1,
+			merchantCount: 1,
+			subtotal:      25000,
+			want:          model.EstimateFees{DeliveryFee: 7000},
+		},
+		{
+			name:          "traffic multiplier only scale the delivery fee",
+			traffic:       rush,
+			distanceKm:    1,
+			merchantCount: 2,
+			subtotal:      10000,
+			want:          model.EstimateFees{DeliveryFee: 10500, ExtraMerchantFee: 3000, SmallOrderFee: 2000},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calculateFees(cfg, tt.traffic, tt.distanceKm, tt.merchantCount, tt.subtotal)
+			if got != tt.want {
+				t.Errorf("calculateFees() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"beli-mang/model"
+	cerr "beli-mang/pkg/customErr"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// selectItemOptions resolve the selected option ids against the option groups of the item,
+// it returns the reason when the selection doesn't follow the group rules.
+func selectItemOptions(groups []model.ItemOptionGroup, optionIds []uuid.UUID) ([]model.BoughtItemOption, string) {
+	type optionRef struct {
+		group  *model.ItemOptionGroup
+		option model.ItemOption
+	}
+	refs := make(map[uuid.UUID]optionRef)
+	for i := range groups {
+		for _, option := range groups[i].Options {
+			refs[option.ID] = optionRef{group: &groups[i], option: option}
+		}
+	}
+
+	selected := make([]model.BoughtItemOption, 0, len(optionIds))
+	counts := make(map[uuid.UUID]int)
+	seen := make(map[uuid.UUID]bool)
+	for _, id := range optionIds {
+		ref, ok := refs[id]
+		if !ok {
+			return nil, fmt.Sprintf("option %s not found", id)
+		}
+		if seen[id] {
+			return nil, fmt.Sprintf("option %s is selected more than once", ref.option.Name)
+		}
+		if !ref.option.IsAvailable {
+			return nil, fmt.Sprintf("option %s is not available", ref.option.Name)
+		}
+		seen[id] = true
+		counts[ref.group.ID]++
+		selected = append(selected, model.BoughtItemOption{
+			OptionId:   id,
+			GroupName:  ref.group.Name,
+			Name:       ref.option.Name,
+			PriceDelta: ref.option.PriceDelta,
+		})
+	}
+
+	for _, group := range groups {
+		if count := counts[group.ID]; count < group.MinSelect || count > group.MaxSelect {
+			return nil, fmt.Sprintf("%s needs between %d and %d options", group.Name, group.MinSelect, group.MaxSelect)
+		}
+	}
+	return selected, ""
+}
+
+// parseOptionIds parse the selected option ids of an order item, the ids are already validated as uuid
+func parseOptionIds(options []string) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(options))
+	for _, option := range options {
+		id, err := uuid.Parse(option)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (s *merchantSvc) GetItemOptionGroups(ctx context.Context, merchantId, itemId uuid.UUID) (groups []model.ItemOptionGroup, err error) {
+	_, err = s.repo.GetMerchantItemById(ctx, merchantId, itemId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, cerr.New(http.StatusNotFound, "item not found")
+		}
+		return nil, err
+	}
+
+	mapGroups, err := s.repo.GetItemOptionGroupMapByItemIds(ctx, []uuid.UUID{itemId})
+	if err != nil {
+		return nil, err
+	}
+	groups = mapGroups[itemId]
+	if groups == nil {
+		groups = []model.ItemOptionGroup{}
+	}
+	return groups, nil
+}
+
+// SetItemOptionGroups replace the option groups of the item,
+// linked item must be an Additions or Condiments item of the same merchant.
+func (s *merchantSvc) SetItemOptionGroups(ctx context.Context, merchantId, itemId uuid.UUID, request model.SetItemOptionGroupsRequest) (groups []model.ItemOptionGroup, err error) {
+	_, err = s.repo.GetMerchantItemById(ctx, merchantId, itemId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, cerr.New(http.StatusNotFound, "item not found")
+		}
+		return nil, err
+	}
+
+	var linkedIds []uuid.UUID
+	groups = make([]model.ItemOptionGroup, 0, len(request.OptionGroups))
+	for _, groupRequest := range request.OptionGroups {
+		if groupRequest.MaxSelect > len(groupRequest.Options) {
+			return nil, cerr.New(http.StatusBadRequest, fmt.Sprintf("%s has less options than maxSelect", groupRequest.Name))
+		}
+		group := model.ItemOptionGroup{
+			ID:        uuid.New(),
+			ItemId:    itemId,
+			Name:      groupRequest.Name,
+			MinSelect: groupRequest.MinSelect,
+			MaxSelect: groupRequest.MaxSelect,
+			Options:   make([]model.ItemOption, 0, len(groupRequest.Options)),
+		}
+		for _, optionRequest := range groupRequest.Options {
+			option := model.ItemOption{
+				ID:          uuid.New(),
+				GroupId:     group.ID,
+				Name:        optionRequest.Name,
+				PriceDelta:  optionRequest.PriceDelta,
+				IsAvailable: optionRequest.IsAvailable == nil || *optionRequest.IsAvailable,
+			}
+			if optionRequest.LinkedItemId != nil {
+				linkedId, _ := uuid.Parse(*optionRequest.LinkedItemId)
+				option.LinkedItemId = &linkedId
+				linkedIds = append(linkedIds, linkedId)
+			}
+			group.Options = append(group.Options, option)
+		}
+		groups = append(groups, group)
+	}
+
+	if len(linkedIds) > 0 {
+		linkedItems, err := s.repo.GetMerchantItemMapByIds(ctx, linkedIds)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range linkedIds {
+			linked, ok := linkedItems[id]
+			if !ok || linked.MerchantId != merchantId {
+				return nil, cerr.New(http.StatusBadRequest, fmt.Sprintf("linked item %s not found", id))
+			}
+			if linked.Category != model.ItemCategoryAdditions && linked.Category != model.ItemCategoryCondiments {
+				return nil, cerr.New(http.StatusBadRequest, fmt.Sprintf("linked item %s must be Additions or Condiments", linked.Name))
+			}
+		}
+	}
+
+	err = s.repo.ReplaceItemOptionGroups(ctx, itemId, groups)
+	if err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
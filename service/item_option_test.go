@@ -0,0 +1,95 @@
+package service
+
+import (
+	"beli-mang/model"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSelectItemOptions(t *testing.T) {
+	var (
+		large   = model.ItemOption{ID: uuid.MustParse("00000000-0000-0000-0000-0000000000a1"), Name: "Large", PriceDelta: 5000, IsAvailable: true}
+		regular = model.ItemOption{ID: uuid.MustParse("00000000-0000-0000-0000-0000000000a2"), Name: "Regular", IsAvailable: true}
+		cheese  = model.ItemOption{ID: uuid.MustParse("00000000-0000-0000-0000-0000000000b1"), Name: "Cheese", PriceDelta: 3000, IsAvailable: true}
+		egg     = model.ItemOption{ID: uuid.MustParse("00000000-0000-0000-0000-0000000000b2"), Name: "Egg", PriceDelta: 4000, IsAvailable: true}
+		bacon   = model.ItemOption{ID: uuid.MustParse("00000000-0000-0000-0000-0000000000b3"), Name: "Bacon", PriceDelta: 6000, IsAvailable: false}
+		unknown = uuid.MustParse("00000000-0000-0000-0000-0000000000ff")
+	)
+	groups := []model.ItemOptionGroup{
+		{ID: uuid.MustParse("00000000-0000-0000-0000-00000000000a"), Name: "Size", MinSelect: 1, MaxSelect: 1, Options: []model.ItemOption{large, regular}},
+		{ID: uuid.MustParse("00000000-0000-0000-0000-00000000000b"), Name: "Topping", MinSelect: 0, MaxSelect: 2, Options: []model.ItemOption{cheese, egg, bacon}},
+	}
+
+	tests := []struct {
+		name       string
+		groups     []model.ItemOptionGroup
+		optionIds  []uuid.UUID
+		want       []model.BoughtItemOption
+		wantReason string
+	}{
+		{
+			name:      "required group only",
+			groups:    groups,
+			optionIds: []uuid.UUID{regular.ID},
+			want:      []model.BoughtItemOption{{OptionId: regular.ID, GroupName: "Size", Name: "Regular"}},
+		},
+		{
+			name:      "snapshot keep the selection order",
+			groups:    groups,
+			optionIds: []uuid.UUID{egg.ID, large.ID, cheese.ID},
+			want: []model.BoughtItemOption{
+				{OptionId: egg.ID, GroupName: "Topping", Name: "Egg", PriceDelta: 4000},
+				{OptionId: large.ID, GroupName: "Size", Name: "Large", PriceDelta: 5000},
+				{OptionId: cheese.ID, GroupName: "Topping", Name: "Cheese", PriceDelta: 3000},
+			},
+		},
+		{
+			name:      "item without group",
+			optionIds: nil,
+			want:      []model.BoughtItemOption{},
+		},
+		{
+			name:       "below the minimum",
+			groups:     groups,
+			optionIds:  []uuid.UUID{cheese.ID},
+			wantReason: "Size needs between 1 and 1 options",
+		},
+		{
+			name:       "above the maximum",
+			groups:     groups,
+			optionIds:  []uuid.UUID{large.ID, regular.ID},
+			wantReason: "Size needs between 1 and 1 options",
+		},
+		{
+			name:       "option of another item",
+			groups:     groups,
+			optionIds:  []uuid.UUID{regular.ID, unknown},
+			wantReason: "option " + unknown.String() + " not found",
+		},
+		{
+			name:       "selected twice",
+			groups:     groups,
+			optionIds:  []uuid.UUID{regular.ID, cheese.ID, cheese.ID},
+			wantReason: "option Cheese is selected more than once",
+		},
+		{
+			name:       "unavailable option",
+			groups:     groups,
+			optionIds:  []uuid.UUID{regular.ID, bacon.ID},
+			wantReason: "option Bacon is not available",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := selectItemOptions(tt.groups, tt.optionIds)
+			if reason != tt.wantReason {
+				t.Fatalf("selectItemOptions() reason = %q, want %q", reason, tt.wantReason)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectItemOptions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"beli-mang/model"
+	cerr "beli-mang/pkg/customErr"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GetItemPriceTimeline returns every price version of the item and mark the one used right now
+func (s *merchantSvc) GetItemPriceTimeline(ctx context.Context, merchantId, itemId uuid.UUID) (prices []model.ItemPrice, err error) {
+	item, err := s.repo.GetMerchantItemById(ctx, merchantId, itemId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, cerr.New(http.StatusNotFound, "item not found")
+		}
+		return nil, err
+	}
+
+	prices, err = s.repo.GetItemPrices(ctx, itemId)
+	if err != nil {
+		return nil, err
+	}
+	if item.PriceId != nil {
+		for i := range prices {
+			prices[i].IsCurrent = prices[i].ID == *item.PriceId
+		}
+	}
+	return prices, nil
+}
+
+// ScheduleItemPrice add a price version, it overrides the earlier versions while it is in effect
+func (s *merchantSvc) ScheduleItemPrice(ctx context.Context, merchantId, itemId uuid.UUID, request model.ScheduleItemPriceRequest, createdBy uuid.UUID) (price model.ItemPrice, err error) {
+	_, err = s.repo.GetMerchantItemById(ctx, merchantId, itemId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return price, cerr.New(http.StatusNotFound, "item not found")
+		}
+		return price, err
+	}
+
+	now := time.Now()
+	price = model.ItemPrice{
+		ID:            uuid.New(),
+		ItemId:        itemId,
+		Price:         request.Price,
+		EffectiveFrom: now,
+		EffectiveTo:   request.EffectiveTo,
+		CreatedBy:     &createdBy,
+		CreatedAt:     now,
+	}
+	if request.EffectiveFrom != nil {
+		price.EffectiveFrom = *request.EffectiveFrom
+	}
+	if price.EffectiveTo != nil {
+		if !price.EffectiveTo.After(price.EffectiveFrom) {
+			return price, cerr.New(http.StatusBadRequest, "effectiveTo must be after effectiveFrom")
+		}
+		if !price.EffectiveTo.After(now) {
+			return price, cerr.New(http.StatusBadRequest, "effectiveTo must be in the future")
+		}
+	}
+
+	err = s.repo.InsertItemPrice(ctx, price)
+	if err != nil {
+		return price, err
+	}
+	price.IsCurrent = price.IsEffectiveAt(now)
+	return price, nil
+}
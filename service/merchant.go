@@ -5,6 +5,9 @@ import (
 	cerr "beli-mang/pkg/customErr"
 	"beli-mang/repo"
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -12,10 +15,22 @@ import (
 )
 
 type MerchantService interface {
-	CreateMerchant(request model.CreateMerchantRequest) (merchantId string, err error)
+	CreateMerchant(ctx context.Context, request model.CreateMerchantRequest, ownerId uuid.UUID) (merchantId string, err error)
 	GetMerchant(ctx context.Context, params model.GetMerchantParams) (listMerchant []model.Merchant, meta model.MetaData, err error)
 	CreateMerchantItem(ctx context.Context, request model.CreateMerchantItemRequest, merchantId uuid.UUID) (itemId string, err error)
+	ImportMerchantItems(ctx context.Context, merchantId uuid.UUID, requests []model.CreateMerchantItemRequest, dryRun bool) (itemIds []string, err error)
 	GetMerchantItem(ctx context.Context, merchantId uuid.UUID, params model.GetMerchantItemParams) (listMerchant []model.MerchantItem, meta model.MetaData, err error)
+	UpdateMerchant(ctx context.Context, merchantId uuid.UUID, request model.UpdateMerchantRequest) (merchant model.Merchant, err error)
+	DeleteMerchant(ctx context.Context, merchantId uuid.UUID) error
+	UpdateMerchantItem(ctx context.Context, merchantId, itemId uuid.UUID, request model.UpdateMerchantItemRequest, changedBy uuid.UUID) (item model.MerchantItem, err error)
+	DeleteMerchantItem(ctx context.Context, merchantId, itemId uuid.UUID) error
+	GetMerchantSchedule(ctx context.Context, merchantId uuid.UUID) (schedule model.MerchantSchedule, err error)
+	UpdateMerchantSchedule(ctx context.Context, schedule model.MerchantSchedule) error
+	GetItemOptionGroups(ctx context.Context, merchantId, itemId uuid.UUID) (groups []model.ItemOptionGroup, err error)
+	SetItemOptionGroups(ctx context.Context, merchantId, itemId uuid.UUID, request model.SetItemOptionGroupsRequest) (groups []model.ItemOptionGroup, err error)
+	GetItemPriceTimeline(ctx context.Context, merchantId, itemId uuid.UUID) (prices []model.ItemPrice, err error)
+	ScheduleItemPrice(ctx context.Context, merchantId, itemId uuid.UUID, request model.ScheduleItemPriceRequest, createdBy uuid.UUID) (price model.ItemPrice, err error)
+	ExportCatalog(ctx context.Context, params model.CatalogExportParams, fn func(model.CatalogRow) error) error
 }
 
 type merchantSvc struct {
@@ -28,18 +43,28 @@ func NewMerchantService(r repo.MerchantRepository) MerchantService {
 	}
 }
 
-func (s *merchantSvc) CreateMerchant(request model.CreateMerchantRequest) (merchantId string, err error) {
+// CreateMerchant create the merchant and record the creator as its owner
+func (s *merchantSvc) CreateMerchant(ctx context.Context, request model.CreateMerchantRequest, ownerId uuid.UUID) (merchantId string, err error) {
 	id := uuid.New()
 
 	merchant := model.Merchant{
-		ID:       id,
-		Name:     request.Name,
-		Category: model.MerchantCategory(request.Category),
-		ImageURL: request.ImageURL,
-		Location: request.Location,
+		ID:               id,
+		Name:             request.Name,
+		Category:         model.MerchantCategory(request.Category),
+		ImageURL:         request.ImageURL,
+		Location:         request.Location,
+		PrepTimeMinutes:  request.PrepTimeMinutes,
+		DeliveryRadiusKm: request.DeliveryRadiusKm,
 	}
 
-	err = s.repo.CreateMerchant(merchant)
+	owner := model.MerchantStaff{
+		MerchantId: id,
+		StaffId:    ownerId,
+		Role:       model.MerchantStaffOwner,
+		CreatedAt:  time.Now(),
+	}
+
+	err = s.repo.CreateMerchant(ctx, merchant, owner)
 	if err != nil {
 		return "", err
 	}
@@ -64,15 +89,18 @@ func (s *merchantSvc) CreateMerchantItem(ctx context.Context, request model.Crea
 	}
 
 	id := uuid.New()
+	priceId := uuid.New()
 
 	merchantItem := model.MerchantItem{
-		ID:         id,
-		MerchantId: merchantId,
-		Name:       request.Name,
-		Category:   request.ProductCategory,
-		ImageURL:   request.ImageURL,
-		Price:      request.Price,
-		CreatedAt:  time.Now(),
+		ID:              id,
+		MerchantId:      merchantId,
+		Name:            request.Name,
+		Category:        request.ProductCategory,
+		ImageURL:        request.ImageURL,
+		Price:           request.Price,
+		PriceId:         &priceId,
+		PrepTimeMinutes: request.PrepTimeMinutes,
+		CreatedAt:       time.Now(),
 	}
 
 	err = s.repo.CreateMerchantItem(merchantItem)
@@ -83,6 +111,47 @@ func (s *merchantSvc) CreateMerchantItem(ctx context.Context, request model.Crea
 	return id.String(), nil
 }
 
+// ImportMerchantItems insert the already validated rows at once, dry run only checks the merchant
+func (s *merchantSvc) ImportMerchantItems(ctx context.Context, merchantId uuid.UUID, requests []model.CreateMerchantItemRequest, dryRun bool) (itemIds []string, err error) {
+	_, err = s.repo.GetMerchantById(ctx, merchantId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, cerr.New(http.StatusNotFound, "merchant not found")
+		}
+		return nil, err
+	}
+	if dryRun {
+		return nil, nil
+	}
+
+	now := time.Now()
+	items := make([]model.MerchantItem, 0, len(requests))
+	itemIds = make([]string, 0, len(requests))
+	for _, request := range requests {
+		id := uuid.New()
+		priceId := uuid.New()
+		items = append(items, model.MerchantItem{
+			ID:              id,
+			MerchantId:      merchantId,
+			Name:            request.Name,
+			Category:        request.ProductCategory,
+			ImageURL:        request.ImageURL,
+			Price:           request.Price,
+			PriceId:         &priceId,
+			PrepTimeMinutes: request.PrepTimeMinutes,
+			IsAvailable:     true,
+			CreatedAt:       now,
+		})
+		itemIds = append(itemIds, id.String())
+	}
+
+	err = s.repo.CreateMerchantItems(ctx, items)
+	if err != nil {
+		return nil, err
+	}
+	return itemIds, nil
+}
+
 func (s *merchantSvc) GetMerchantItem(ctx context.Context, merchantId uuid.UUID, params model.GetMerchantItemParams) (listMerchantItem []model.MerchantItem, meta model.MetaData, err error) {
 	listMerchantItem = []model.MerchantItem{}
 	_, err = s.repo.GetMerchantById(ctx, merchantId)
@@ -98,3 +167,157 @@ func (s *merchantSvc) GetMerchantItem(ctx context.Context, merchantId uuid.UUID,
 
 	return listMerchantItem, meta, nil
 }
+
+func (s *merchantSvc) UpdateMerchant(ctx context.Context, merchantId uuid.UUID, request model.UpdateMerchantRequest) (merchant model.Merchant, err error) {
+	merchant, err = s.repo.GetMerchantById(ctx, merchantId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return merchant, cerr.New(http.StatusNotFound, "merchant not found")
+		}
+		return merchant, err
+	}
+
+	if request.Name != nil {
+		merchant.Name = *request.Name
+	}
+	if request.Category != nil {
+		merchant.Category = model.MerchantCategory(*request.Category)
+	}
+	if request.ImageURL != nil {
+		merchant.ImageURL = *request.ImageURL
+	}
+	if request.Location != nil {
+		merchant.Location = *request.Location
+	}
+	if request.PrepTimeMinutes != nil {
+		merchant.PrepTimeMinutes = request.PrepTimeMinutes
+	}
+	if request.DeliveryRadiusKm != nil {
+		merchant.DeliveryRadiusKm = request.DeliveryRadiusKm
+	}
+
+	err = s.repo.UpdateMerchant(ctx, merchant)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return merchant, cerr.New(http.StatusNotFound, "merchant not found")
+		}
+		return merchant, err
+	}
+
+	return merchant, nil
+}
+
+func (s *merchantSvc) DeleteMerchant(ctx context.Context, merchantId uuid.UUID) error {
+	err := s.repo.DeleteMerchant(ctx, merchantId, time.Now())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return cerr.New(http.StatusNotFound, "merchant not found")
+		}
+		return err
+	}
+	return nil
+}
+
+// UpdateMerchantItem only update the field that is sent, a new price is effective immediately
+func (s *merchantSvc) UpdateMerchantItem(ctx context.Context, merchantId, itemId uuid.UUID, request model.UpdateMerchantItemRequest, changedBy uuid.UUID) (item model.MerchantItem, err error) {
+	item, err = s.repo.GetMerchantItemById(ctx, merchantId, itemId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return item, cerr.New(http.StatusNotFound, "item not found")
+		}
+		return item, err
+	}
+
+	if request.Name != nil {
+		item.Name = *request.Name
+	}
+	if request.ProductCategory != nil {
+		item.Category = *request.ProductCategory
+	}
+	if request.ImageURL != nil {
+		item.ImageURL = *request.ImageURL
+	}
+	var newPrice *model.ItemPrice
+	if request.Price != nil && *request.Price != item.Price {
+		now := time.Now()
+		newPrice = &model.ItemPrice{
+			ID:            uuid.New(),
+			ItemId:        item.ID,
+			Price:         *request.Price,
+			EffectiveFrom: now,
+			CreatedBy:     &changedBy,
+			CreatedAt:     now,
+		}
+		item.Price = newPrice.Price
+		item.PriceId = &newPrice.ID
+	}
+	if request.IsAvailable != nil {
+		item.IsAvailable = *request.IsAvailable
+	}
+	if request.PrepTimeMinutes != nil {
+		item.PrepTimeMinutes = request.PrepTimeMinutes
+	}
+
+	err = s.repo.UpdateMerchantItem(ctx, item, newPrice)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return item, cerr.New(http.StatusNotFound, "item not found")
+		}
+		return item, err
+	}
+
+	return item, nil
+}
+
+func (s *merchantSvc) DeleteMerchantItem(ctx context.Context, merchantId, itemId uuid.UUID) error {
+	err := s.repo.DeleteMerchantItem(ctx, merchantId, itemId, time.Now())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return cerr.New(http.StatusNotFound, "item not found")
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *merchantSvc) GetMerchantSchedule(ctx context.Context, merchantId uuid.UUID) (schedule model.MerchantSchedule, err error) {
+	schedules, err := s.repo.GetMerchantScheduleMapByIds(ctx, []uuid.UUID{merchantId})
+	if err != nil {
+		return schedule, err
+	}
+	schedule, ok := schedules[merchantId]
+	if !ok {
+		return schedule, cerr.New(http.StatusNotFound, "merchant not found")
+	}
+	if schedule.OpeningHours == nil {
+		schedule.OpeningHours = []model.OpeningHour{}
+	}
+	if schedule.Overrides == nil {
+		schedule.Overrides = []model.ScheduleOverride{}
+	}
+	return schedule, nil
+}
+
+func (s *merchantSvc) UpdateMerchantSchedule(ctx context.Context, schedule model.MerchantSchedule) error {
+	dates := make(map[string]bool)
+	for _, o := range schedule.Overrides {
+		if dates[o.Date] {
+			return cerr.New(http.StatusBadRequest, fmt.Sprintf("duplicate override for %s", o.Date))
+		}
+		dates[o.Date] = true
+	}
+
+	err := s.repo.ReplaceMerchantSchedule(ctx, schedule)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return cerr.New(http.StatusNotFound, "merchant not found")
+		}
+		return err
+	}
+	return nil
+}
+
+// ExportCatalog stream the catalog row by row into fn
+func (s *merchantSvc) ExportCatalog(ctx context.Context, params model.CatalogExportParams, fn func(model.CatalogRow) error) error {
+	return s.repo.StreamCatalog(ctx, params, fn)
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"beli-mang/model"
+	"beli-mang/repo"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// canManageOrder reports whether the staff manages every merchant in the order, a status change
+// moves the whole order so the staff of one leg can't make it. super admin can manage every order.
+func canManageOrder(ctx context.Context, merchantRepo repo.MerchantRepository, order model.Order, staffId uuid.UUID, role model.Role) (bool, error) {
+	switch role {
+	case model.RoleSuperAdmin:
+		return true, nil
+	case model.RoleAdmin:
+		return merchantRepo.IsStaffOfAllMerchants(ctx, staffId, orderMerchantIds(order))
+	default:
+		return false, nil
+	}
+}
+
+// canViewOrder reports whether the order can be seen by the user who placed it,
+// by the staff managing one of its merchants or by super admin.
+func canViewOrder(ctx context.Context, merchantRepo repo.MerchantRepository, order model.Order, userId uuid.UUID, role model.Role) (bool, error) {
+	if order.UserID == userId {
+		return true, nil
+	}
+	switch role {
+	case model.RoleSuperAdmin:
+		return true, nil
+	case model.RoleAdmin:
+		return merchantRepo.IsMerchantStaff(ctx, userId, orderMerchantIds(order))
+	default:
+		return false, nil
+	}
+}
+
+func orderMerchantIds(order model.Order) []uuid.UUID {
+	merchantIds := make([]uuid.UUID, 0, len(order.Detail))
+	for _, leg := range order.Detail {
+		merchantIds = append(merchantIds, leg.Merchant.ID)
+	}
+	return merchantIds
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"beli-mang/model"
+	cerr "beli-mang/pkg/customErr"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+func (s *purchaseSvc) GetMerchantOrders(ctx context.Context, params model.MerchantOrdersParams) (response model.GetMerchantOrdersResponse, err error) {
+	response = model.GetMerchantOrdersResponse{}
+	if params.Limit == 0 {
+		params.Limit = 5 // default limit
+	}
+
+	listData, err := s.orderRepo.GetMerchantOrders(ctx, params)
+	if err != nil {
+		return response, err
+	}
+
+	for _, order := range listData {
+		for _, leg := range order.Detail {
+			if leg.Merchant.ID != params.MerchantId {
+				continue
+			}
+			response = append(response, model.MerchantOrderData{
+				OrderId:     order.OrderID,
+				OrderStatus: order.OrderStatus,
+				Order:       leg,
+				UserLocation: model.UserLocation{
+					Lat:  order.UserLatitude,
+					Long: order.UserLongitude,
+				},
+				CreatedAt: order.CreatedAt,
+			})
+		}
+	}
+
+	return response, nil
+}
+
+func (s *purchaseSvc) AcceptMerchantOrder(ctx context.Context, request model.MerchantOrderActionRequest) (response model.MerchantOrderActionResponse, err error) {
+	return s.decideMerchantOrder(ctx, request, model.OrderStatusAccepted)
+}
+
+func (s *purchaseSvc) RejectMerchantOrder(ctx context.Context, request model.MerchantOrderActionRequest) (response model.MerchantOrderActionResponse, err error) {
+	return s.decideMerchantOrder(ctx, request, model.OrderStatusRejected)
+}
+
+// decideMerchantOrder mark the merchant leg of an order as accepted or rejected.
+// once every leg is decided the order itself move to ACCEPTED, or REJECTED when no leg is left.
+func (s *purchaseSvc) decideMerchantOrder(ctx context.Context, request model.MerchantOrderActionRequest, decision model.OrderStatus) (response model.MerchantOrderActionResponse, err error) {
+	// rejecting re-plan the route, it is done before the tx so the order isn't locked while calling the router
+	var planned model.OrderDetail
+	var replanned model.CalculatedEstimate
+	if decision == model.OrderStatusRejected {
+		planned, replanned, err = s.planRejection(ctx, request)
+		if err != nil {
+			return response, err
+		}
+	}
+
+	tx, err := s.orderRepo.BeginTx(ctx)
+	if err != nil {
+		return response, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	order, err := s.orderRepo.GetOrderByIdForUpdate(ctx, tx, request.OrderId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return response, cerr.New(http.StatusNotFound, "order not found")
+		}
+		return response, err
+	}
+
+	legIdx := merchantLegIndex(order.Detail, request.MerchantId)
+	if legIdx == -1 || order.OrderStatus == model.OrderStatusDraft {
+		return response, cerr.New(http.StatusNotFound, "order not found")
+	}
+	if order.OrderStatus != model.OrderStatusCreated {
+		return response, cerr.New(http.StatusBadRequest, fmt.Sprintf("order can't be %s in status %s", decision, order.OrderStatus))
+	}
+	if order.Detail[legIdx].Status != "" {
+		return response, cerr.New(http.StatusConflict, fmt.Sprintf("order already %s by merchant", order.Detail[legIdx].Status))
+	}
+
+	order.Detail[legIdx].Status = decision
+	order.DetailRaw, err = json.Marshal(order.Detail)
+	if err != nil {
+		return response, err
+	}
+	err = s.orderRepo.UpdateDetail(ctx, tx, order)
+	if err != nil {
+		return response, err
+	}
+
+	var estimate model.CalculatedEstimate
+	if decision == model.OrderStatusRejected {
+		// another leg rejected in the meantime changes the route, the plan is stale
+		if !sameRejectedLegs(planned, order.Detail) {
+			return response, cerr.New(http.StatusConflict, "order changed while it was decided, please retry")
+		}
+		estimate = replanned
+		err = s.orderRepo.UpdateCalculation(ctx, tx, estimate)
+	} else {
+		estimate, err = s.orderRepo.GetCalculatedEstimateByOrderIdForUpdate(ctx, tx, order.OrderID)
+	}
+	if err != nil {
+		return response, err
+	}
+
+	orderStatus := order.OrderStatus
+	if next, decided := aggregateLegsStatus(order.Detail); decided {
+		err = s.transitionOrderStatus(ctx, tx, order.OrderID, order.OrderStatus, next, request.ChangedBy)
+		if err != nil {
+			return response, err
+		}
+		orderStatus = next
+	}
+
+	return model.MerchantOrderActionResponse{
+		OrderId:                        order.OrderID,
+		OrderStatus:                    orderStatus,
+		MerchantStatus:                 decision,
+		TotalPrice:                     estimate.TotalPrice,
+		EstimatedDeliveryTimeInMinutes: estimate.EstimatedDeliveryTimeInMinutes,
+	}, nil
+}
+
+// planRejection returns the order detail with the merchant leg rejected and its estimate,
+// price, fees, promo discount and eta only count the remaining legs
+func (s *purchaseSvc) planRejection(ctx context.Context, request model.MerchantOrderActionRequest) (detail model.OrderDetail, calculatedData model.CalculatedEstimate, err error) {
+	order, err := s.orderRepo.GetOrderById(ctx, request.OrderId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return detail, calculatedData, cerr.New(http.StatusNotFound, "order not found")
+		}
+		return detail, calculatedData, err
+	}
+	legIdx := merchantLegIndex(order.Detail, request.MerchantId)
+	if legIdx == -1 {
+		return detail, calculatedData, cerr.New(http.StatusNotFound, "order not found")
+	}
+	order.Detail[legIdx].Status = model.OrderStatusRejected
+
+	estimate, err := s.orderRepo.GetCalculatedEstimateByOrderId(ctx, order.OrderID)
+	if err != nil {
+		return detail, calculatedData, err
+	}
+
+	subtotal := activeLegsTotalPrice(order.Detail)
+	promo, err := s.activeLegsPromo(ctx, estimate.PromoCodeId, order.Detail)
+	if err != nil {
+		return detail, calculatedData, err
+	}
+	discount := promo.DiscountAmount
+	merchants := routeMerchants(order.Detail)
+	// the traffic of the estimate, the user agreed on its fees
+	traffic := freeFlowTraffic
+	if len(merchants) > 0 {
+		start := model.Point{Lat: merchants[0].Location.Lat, Lon: merchants[0].Location.Long}
+		traffic = trafficAt(s.cfg.SpeedProfiles, start, estimate.CreatedAt)
+	}
+	route, visitOrder, wait, err := s.planRoute(ctx, merchants, merchantsPrepTime(order.Detail), model.Point{Lat: order.UserLatitude, Lon: order.UserLongitude}, traffic)
+	if err != nil {
+		return detail, calculatedData, err
+	}
+	calculatedData = model.CalculatedEstimate{
+		OrderId:              order.OrderID,
+		SubtotalPrice:        subtotal,
+		DiscountAmount:       discount,
+		DiscountBreakdownRaw: json.RawMessage(`[]`),
+		RouteDistanceInKm:    route.DistanceKm,
+		VisitOrder:           visitOrder,
+	}
+	if len(promo.Breakdown) > 0 {
+		calculatedData.DiscountBreakdownRaw, err = json.Marshal(promo.Breakdown)
+		if err != nil {
+			return detail, calculatedData, err
+		}
+	}
+	calculatedData.SetDeliveryTime(wait, route.Duration)
+	// nothing is delivered anymore once every leg is rejected
+	if len(merchants) > 0 {
+		calculatedData.EstimateFees = calculateFees(s.cfg.Fee, traffic, route.DistanceKm, len(merchants), subtotal)
+	}
+	calculatedData.TotalPrice = subtotal + calculatedData.EstimateFees.Total() - discount
+	return order.Detail, calculatedData, nil
+}
+
+// merchantLegIndex returns the index of the merchant leg in the detail, or -1
+func merchantLegIndex(detail model.OrderDetail, merchantId uuid.UUID) int {
+	for i, leg := range detail {
+		if leg.Merchant.ID == merchantId {
+			return i
+		}
+	}
+	return -1
+}
+
+// sameRejectedLegs reports whether both details reject the same legs
+func sameRejectedLegs(a, b model.OrderDetail) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if (a[i].Status == model.OrderStatusRejected) != (b[i].Status == model.OrderStatusRejected) {
+			return false
+		}
+	}
+	return true
+}
+
+// aggregateLegsStatus returns the order status once every merchant has decided on its leg
+func aggregateLegsStatus(detail model.OrderDetail) (status model.OrderStatus, decided bool) {
+	accepted := 0
+	for _, leg := range detail {
+		switch leg.Status {
+		case "":
+			return "", false
+		case model.OrderStatusAccepted:
+			accepted++
+		}
+	}
+	if accepted == 0 {
+		return model.OrderStatusRejected, true
+	}
+	return model.OrderStatusAccepted, true
+}
+
+// activeLegsTotalPrice sum the price of every leg that is not rejected
+func activeLegsTotalPrice(detail model.OrderDetail) int {
+	total := 0
+	for _, leg := range detail {
+		if leg.Status == model.OrderStatusRejected {
+			continue
+		}
+		total += leg.TotalPrice()
+	}
+	return total
+}
+
+// activeLegsPromo apply the promo of the estimate again on the legs that are not rejected,
+// the discount is dropped when they don't qualify anymore, e.g. below the minimum spend
+func (s *purchaseSvc) activeLegsPromo(ctx context.Context, promoCodeId *uuid.UUID, detail model.OrderDetail) (model.AppliedPromo, error) {
+	if promoCodeId == nil {
+		return model.AppliedPromo{}, nil
+	}
+	var active model.OrderDetail
+	for _, leg := range detail {
+		if leg.Status != model.OrderStatusRejected {
+			active = append(active, leg)
+		}
+	}
+	promo, err := s.promoRepo.GetPromoCodeById(ctx, *promoCodeId)
+	if err != nil {
+		return model.AppliedPromo{}, err
+	}
+	applied, reason := applyPromo(promo, active)
+	if reason != "" {
+		return model.AppliedPromo{}, nil
+	}
+	return applied, nil
+}
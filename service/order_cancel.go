@@ -0,0 +1,155 @@
+package service
+
+import (
+	"beli-mang/model"
+	cerr "beli-mang/pkg/customErr"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// cancelPolicy define which reason and which current status is allowed for each cancel initiator,
+// user can only cancel before the food is prepared, merchant can still cancel while preparing.
+var cancelPolicy = map[model.CancelInitiator]struct {
+	reasons  []model.CancelReasonCode
+	statuses []model.OrderStatus
+}{
+	model.CancelInitiatorUser: {
+		reasons:  []model.CancelReasonCode{model.CancelReasonChangedMind, model.CancelReasonOrderedByMistake, model.CancelReasonDeliveryTooLong, model.CancelReasonOther},
+		statuses: []model.OrderStatus{model.OrderStatusCreated, model.OrderStatusAccepted},
+	},
+	model.CancelInitiatorMerchant: {
+		reasons:  []model.CancelReasonCode{model.CancelReasonOutOfStock, model.CancelReasonMerchantClosed, model.CancelReasonMerchantTooBusy, model.CancelReasonOther},
+		statuses: []model.OrderStatus{model.OrderStatusCreated, model.OrderStatusAccepted, model.OrderStatusPreparing},
+	},
+}
+
+func (s *purchaseSvc) CancelUserOrder(ctx context.Context, request model.CancelOrderRequest) (response model.CancelOrderResponse, err error) {
+	return s.cancelOrder(ctx, request, model.CancelInitiatorUser)
+}
+
+func (s *purchaseSvc) CancelMerchantOrder(ctx context.Context, request model.CancelOrderRequest) (response model.CancelOrderResponse, err error) {
+	return s.cancelOrder(ctx, request, model.CancelInitiatorMerchant)
+}
+
+// cancelOrder move the order to CANCELLED and write the refund into the ledger in one transaction
+func (s *purchaseSvc) cancelOrder(ctx context.Context, request model.CancelOrderRequest, initiator model.CancelInitiator) (response model.CancelOrderResponse, err error) {
+	policy := cancelPolicy[initiator]
+	if !containsReason(policy.reasons, request.ReasonCode) {
+		return response, cerr.New(http.StatusBadRequest, fmt.Sprintf("invalid reason code %s", request.ReasonCode))
+	}
+
+	tx, err := s.orderRepo.BeginTx(ctx)
+	if err != nil {
+		return response, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	order, err := s.orderRepo.GetOrderByIdForUpdate(ctx, tx, request.OrderId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return response, cerr.New(http.StatusNotFound, "order not found")
+		}
+		return response, err
+	}
+	if order.OrderStatus == model.OrderStatusDraft {
+		return response, cerr.New(http.StatusNotFound, "order not found")
+	}
+	err = s.checkCanCancelOrder(ctx, order, request, initiator)
+	if err != nil {
+		return response, err
+	}
+	if !containsStatus(policy.statuses, order.OrderStatus) {
+		return response, cerr.New(http.StatusBadRequest, fmt.Sprintf("order can't be cancelled in status %s", order.OrderStatus))
+	}
+
+	err = s.transitionOrderStatus(ctx, tx, order.OrderID, order.OrderStatus, model.OrderStatusCancelled, request.CancelledBy)
+	if err != nil {
+		return response, err
+	}
+
+	calculatedData, err := s.orderRepo.GetCalculatedEstimateByOrderIdForUpdate(ctx, tx, order.OrderID)
+	if err != nil {
+		return response, err
+	}
+
+	refund := model.RefundLedger{
+		ID:                   uuid.New(),
+		OrderID:              order.OrderID,
+		CalculatedEstimateId: calculatedData.CalculatedEstimateId,
+		Amount:               calculatedData.TotalPrice,
+		ReasonCode:           request.ReasonCode,
+		Note:                 request.Note,
+		Initiator:            initiator,
+		InitiatedBy:          request.CancelledBy,
+		CreatedAt:            time.Now(),
+	}
+	err = s.orderRepo.InsertRefundLedger(ctx, tx, refund)
+	if err != nil {
+		return response, err
+	}
+
+	return model.CancelOrderResponse{
+		OrderId:      order.OrderID,
+		OrderStatus:  model.OrderStatusCancelled,
+		RefundId:     refund.ID,
+		RefundAmount: refund.Amount,
+	}, nil
+}
+
+// checkCanCancelOrder checks the order belongs to the user, or the merchant is part of the order
+// and the staff manages every merchant of it, since cancelling refunds the whole order.
+// a merchant that rejected its leg is out of the order and can't cancel it anymore.
+func (s *purchaseSvc) checkCanCancelOrder(ctx context.Context, order model.Order, request model.CancelOrderRequest, initiator model.CancelInitiator) error {
+	if initiator == model.CancelInitiatorUser {
+		if order.UserID != request.CancelledBy {
+			return cerr.New(http.StatusNotFound, "order not found")
+		}
+		return nil
+	}
+
+	legIdx := merchantLegIndex(order.Detail, request.MerchantId)
+	if legIdx == -1 {
+		return cerr.New(http.StatusNotFound, "order not found")
+	}
+	if order.Detail[legIdx].Status == model.OrderStatusRejected {
+		return cerr.New(http.StatusConflict, fmt.Sprintf("order already %s by merchant", order.Detail[legIdx].Status))
+	}
+	allowed, err := canManageOrder(ctx, s.merchantRepo, order, request.CancelledBy, request.Role)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return cerr.New(http.StatusNotFound, "order not found")
+	}
+	return nil
+}
+
+func containsReason(reasons []model.CancelReasonCode, reason model.CancelReasonCode) bool {
+	for _, r := range reasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+func containsStatus(statuses []model.OrderStatus, status model.OrderStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"beli-mang/model"
+	cerr "beli-mang/pkg/customErr"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+)
+
+func (s *purchaseSvc) GetOrderDetail(ctx context.Context, request model.GetOrderDetailRequest) (response model.GetOrderDetailResponse, err error) {
+	order, err := s.orderRepo.GetOrderById(ctx, request.OrderId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return response, cerr.New(http.StatusNotFound, "order not found")
+		}
+		return response, err
+	}
+	if order.OrderStatus == model.OrderStatusDraft {
+		return response, cerr.New(http.StatusNotFound, "order not found")
+	}
+	// order of another user is treated as not found, admin can only see the orders of the merchants they manage
+	allowed, err := canViewOrder(ctx, s.merchantRepo, order, request.UserId, request.Role)
+	if err != nil {
+		return response, err
+	}
+	if !allowed {
+		return response, cerr.New(http.StatusNotFound, "order not found")
+	}
+
+	calculatedData, err := s.orderRepo.GetCalculatedEstimateByOrderId(ctx, order.OrderID)
+	if err != nil {
+		return response, err
+	}
+
+	history, err := s.orderRepo.GetOrderStatusHistory(ctx, order.OrderID, 0)
+	if err != nil {
+		return response, err
+	}
+
+	orders := make([]model.OrderDataSubtotal, 0, len(order.Detail))
+	for _, leg := range order.Detail {
+		orders = append(orders, model.OrderDataSubtotal{
+			OrderData: leg,
+			Subtotal:  leg.TotalPrice(),
+		})
+	}
+
+	return model.GetOrderDetailResponse{
+		OrderId:                        order.OrderID,
+		OrderStatus:                    order.OrderStatus,
+		Orders:                         orders,
+		CalculatedEstimateId:           calculatedData.CalculatedEstimateId,
+		TotalPrice:                     calculatedData.TotalPrice,
+		PriceBreakdown:                 calculatedData.PriceBreakdown(),
+		VisitOrder:                     calculatedData.VisitOrder,
+		EstimatedDeliveryTimeInMinutes: calculatedData.EstimatedDeliveryTimeInMinutes,
+		DeliveryTimeBreakdown:          calculatedData.DeliveryTimeBreakdown(),
+		UserLocation: model.UserLocation{
+			Lat:  order.UserLatitude,
+			Long: order.UserLongitude,
+		},
+		StatusHistory: history,
+		CreatedAt:     order.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"beli-mang/config"
+	"beli-mang/model"
+	cerr "beli-mang/pkg/customErr"
+	"beli-mang/repo"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+const (
+	orderStatusChannel = "order_status"
+	// buffer per subscriber, event is only a signal to re-read the history so dropping is safe
+	orderEventBufferSize = 8
+)
+
+type OrderEventService interface {
+	// Run listen to postgres notification and fan out to subscribers until ctx is done
+	Run(ctx context.Context)
+	// Subscribe returns a channel notified every time the order status changes,
+	// the channel is closed when the service stops or the subscriber falls behind a resync.
+	Subscribe(ctx context.Context, request model.OrderEventsRequest) (events <-chan model.OrderStatusEvent, unsubscribe func(), err error)
+	GetEventsAfter(ctx context.Context, orderId uuid.UUID, afterSeq int64) ([]model.OrderStatusHistory, error)
+}
+
+type orderEventSvc struct {
+	cfg          *config.Config
+	orderRepo    repo.OrderRepository
+	merchantRepo repo.MerchantRepository
+	logger       *zap.Logger
+
+	mu          sync.Mutex
+	stopped     bool
+	subscribers map[uuid.UUID]map[chan model.OrderStatusEvent]struct{}
+}
+
+func NewOrderEventService(cfg *config.Config, orderRepo repo.OrderRepository, merchantRepo repo.MerchantRepository, logger *zap.Logger) OrderEventService {
+	return &orderEventSvc{
+		cfg:          cfg,
+		orderRepo:    orderRepo,
+		merchantRepo: merchantRepo,
+		logger:       logger,
+		subscribers:  make(map[uuid.UUID]map[chan model.OrderStatusEvent]struct{}),
+	}
+}
+
+func (s *orderEventSvc) Run(ctx context.Context) {
+	logPrefix := "[orderEvent] "
+	listener := pq.NewListener(s.cfg.DB.ConnectionString(), 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			s.logger.Error(logPrefix+"listener error", zap.Error(err))
+		}
+	})
+	defer func() {
+		_ = listener.Close()
+		s.closeSubscribers()
+	}()
+
+	if err := listener.Listen(orderStatusChannel); err != nil {
+		s.logger.Error(logPrefix+"failed to listen", zap.Error(err))
+		return
+	}
+
+	ping := time.NewTicker(90 * time.Second)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info(logPrefix + "stopped")
+			return
+		case n := <-listener.Notify:
+			if n == nil {
+				// connection re-established, notification might be lost in between
+				s.logger.Warn(logPrefix + "listener reconnected, resyncing subscribers")
+				s.resync()
+				continue
+			}
+			var event model.OrderStatusEvent
+			if err := json.Unmarshal([]byte(n.Extra), &event); err != nil {
+				s.logger.Error(logPrefix+"invalid notification payload", zap.Error(err))
+				continue
+			}
+			s.publish(event)
+		case <-ping.C:
+			go func() {
+				_ = listener.Ping()
+			}()
+		}
+	}
+}
+
+func (s *orderEventSvc) Subscribe(ctx context.Context, request model.OrderEventsRequest) (events <-chan model.OrderStatusEvent, unsubscribe func(), err error) {
+	order, err := s.orderRepo.GetOrderById(ctx, request.OrderId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, cerr.New(http.StatusNotFound, "order not found")
+		}
+		return nil, nil, err
+	}
+	if order.OrderStatus == model.OrderStatusDraft {
+		return nil, nil, cerr.New(http.StatusNotFound, "order not found")
+	}
+	allowed, err := canViewOrder(ctx, s.merchantRepo, order, request.UserId, request.Role)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !allowed {
+		return nil, nil, cerr.New(http.StatusNotFound, "order not found")
+	}
+
+	ch := make(chan model.OrderStatusEvent, orderEventBufferSize)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return nil, nil, cerr.New(http.StatusServiceUnavailable, "server is shutting down")
+	}
+	if s.subscribers[order.OrderID] == nil {
+		s.subscribers[order.OrderID] = make(map[chan model.OrderStatusEvent]struct{})
+	}
+	s.subscribers[order.OrderID][ch] = struct{}{}
+
+	unsubscribe = func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.subscribers[order.OrderID][ch]; !ok {
+			return
+		}
+		delete(s.subscribers[order.OrderID], ch)
+		if len(s.subscribers[order.OrderID]) == 0 {
+			delete(s.subscribers, order.OrderID)
+		}
+		close(ch)
+	}
+	return ch, unsubscribe, nil
+}
+
+func (s *orderEventSvc) GetEventsAfter(ctx context.Context, orderId uuid.UUID, afterSeq int64) ([]model.OrderStatusHistory, error) {
+	return s.orderRepo.GetOrderStatusHistory(ctx, orderId, afterSeq)
+}
+
+func (s *orderEventSvc) publish(event model.OrderStatusEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.subscribers[event.OrderId] {
+		select {
+		case ch <- event:
+		default:
+			// subscriber is behind, it will pick up this event on the next re-read of the history
+		}
+	}
+}
+
+// resync tell every subscriber to re-read the history, a subscriber that is too far behind to
+// take the signal is closed so the client reconnects with Last-Event-ID
+func (s *orderEventSvc) resync() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for orderId, chans := range s.subscribers {
+		for ch := range chans {
+			select {
+			case ch <- model.OrderStatusEvent{OrderId: orderId, Resync: true}:
+			default:
+				delete(chans, ch)
+				close(ch)
+			}
+		}
+		if len(chans) == 0 {
+			delete(s.subscribers, orderId)
+		}
+	}
+}
+
+func (s *orderEventSvc) closeSubscribers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped = true
+	for orderId, chans := range s.subscribers {
+		for ch := range chans {
+			close(ch)
+		}
+		delete(s.subscribers, orderId)
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"beli-mang/model"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// revalidateOrderDetail compare the items snapshot of an order against the current catalog,
+// it returns every item which price has changed, is sold out or no longer exist.
+// Item which selected options can't be ordered anymore is reported as unavailable.
+func (s *purchaseSvc) revalidateOrderDetail(ctx context.Context, detail model.OrderDetail) (changes model.OrderChanges, err error) {
+	changes = model.OrderChanges{
+		ChangedPrices:    []model.ChangedItemPrice{},
+		MissingItems:     []model.MissingItem{},
+		UnavailableItems: []model.MissingItem{},
+	}
+
+	var itemIds []uuid.UUID
+	for _, leg := range detail {
+		for _, item := range leg.Items {
+			itemIds = append(itemIds, item.ItemId)
+		}
+	}
+	if len(itemIds) == 0 {
+		return changes, nil
+	}
+
+	mapItems, err := s.merchantRepo.GetMerchantItemMapByIds(ctx, itemIds)
+	if err != nil {
+		return changes, err
+	}
+	mapGroups, err := s.merchantRepo.GetItemOptionGroupMapByItemIds(ctx, itemIds)
+	if err != nil {
+		return changes, err
+	}
+
+	for _, leg := range detail {
+		for _, item := range leg.Items {
+			current, ok := mapItems[item.ItemId]
+			if !ok || current.MerchantId != leg.Merchant.ID {
+				changes.MissingItems = append(changes.MissingItems, model.MissingItem{
+					MerchantId: leg.Merchant.ID,
+					ItemId:     item.ItemId,
+					Name:       item.Name,
+				})
+				continue
+			}
+			options, reason := selectItemOptions(mapGroups[item.ItemId], item.OptionIds())
+			if !current.IsAvailable || reason != "" {
+				changes.UnavailableItems = append(changes.UnavailableItems, model.MissingItem{
+					MerchantId: leg.Merchant.ID,
+					ItemId:     item.ItemId,
+					Name:       item.Name,
+				})
+				continue
+			}
+			currentItem := current.ItemToBoughtItem()
+			currentItem.Options = options
+			if currentItem.UnitPrice() != item.UnitPrice() {
+				changes.ChangedPrices = append(changes.ChangedPrices, model.ChangedItemPrice{
+					MerchantId:     leg.Merchant.ID,
+					ItemId:         item.ItemId,
+					Name:           item.Name,
+					EstimatedPrice: item.UnitPrice(),
+					CurrentPrice:   currentItem.UnitPrice(),
+				})
+			}
+		}
+	}
+
+	return changes, nil
+}
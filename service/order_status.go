@@ -0,0 +1,105 @@
+package service
+
+import (
+	"beli-mang/model"
+	cerr "beli-mang/pkg/customErr"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// orderStatusTransitions is the single source of truth of the order lifecycle,
+// key is the current status and value is the list of status it can move to.
+// status without entry is a final status.
+var orderStatusTransitions = map[model.OrderStatus][]model.OrderStatus{
+	model.OrderStatusDraft:     {model.OrderStatusCreated},
+	model.OrderStatusCreated:   {model.OrderStatusAccepted, model.OrderStatusRejected, model.OrderStatusCancelled},
+	model.OrderStatusAccepted:  {model.OrderStatusPreparing, model.OrderStatusCancelled},
+	model.OrderStatusPreparing: {model.OrderStatusPickedUp, model.OrderStatusCancelled},
+	model.OrderStatusPickedUp:  {model.OrderStatusDelivered},
+}
+
+// CanTransitionOrderStatus reports whether an order in `from` status is allowed to move to `to` status
+func CanTransitionOrderStatus(from, to model.OrderStatus) bool {
+	for _, next := range orderStatusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// transitionOrderStatus validates and applies a status change inside the given transaction,
+// and records who changed it into the status history.
+func (s *purchaseSvc) transitionOrderStatus(ctx context.Context, tx *sqlx.Tx, orderID uuid.UUID, from, to model.OrderStatus, changedBy uuid.UUID) error {
+	if !CanTransitionOrderStatus(from, to) {
+		return cerr.New(http.StatusBadRequest, fmt.Sprintf("can't change order status from %s to %s", from, to))
+	}
+
+	err := s.orderRepo.UpdateStatus(ctx, tx, orderID, from, to)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return cerr.New(http.StatusConflict, "order status has been changed by another request")
+		}
+		return err
+	}
+
+	fromStatus := from
+	return s.orderRepo.InsertStatusHistory(ctx, tx, model.OrderStatusHistory{
+		ID:         uuid.New(),
+		OrderID:    orderID,
+		FromStatus: &fromStatus,
+		ToStatus:   to,
+		ChangedBy:  changedBy,
+		CreatedAt:  time.Now(),
+	})
+}
+
+func (s *purchaseSvc) UpdateOrderStatus(ctx context.Context, request model.UpdateOrderStatusRequest) (response model.UpdateOrderStatusResponse, err error) {
+	order, err := s.orderRepo.GetOrderById(ctx, request.OrderID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return response, cerr.New(http.StatusNotFound, "order not found")
+		}
+		return response, err
+	}
+	// a draft only becomes an order through the confirm endpoint, it is not visible here
+	if order.OrderStatus == model.OrderStatusDraft {
+		return response, cerr.New(http.StatusNotFound, "order not found")
+	}
+	allowed, err := canManageOrder(ctx, s.merchantRepo, order, request.ChangedBy, request.Role)
+	if err != nil {
+		return response, err
+	}
+	if !allowed {
+		return response, cerr.New(http.StatusNotFound, "order not found")
+	}
+
+	tx, err := s.orderRepo.BeginTx(ctx)
+	if err != nil {
+		return response, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	err = s.transitionOrderStatus(ctx, tx, order.OrderID, order.OrderStatus, request.Status, request.ChangedBy)
+	if err != nil {
+		return response, err
+	}
+
+	return model.UpdateOrderStatusResponse{
+		OrderId:     order.OrderID,
+		OrderStatus: request.Status,
+	}, nil
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"beli-mang/model"
+	"testing"
+)
+
+var allOrderStatuses = []model.OrderStatus{
+	model.OrderStatusDraft,
+	model.OrderStatusCreated,
+	model.OrderStatusAccepted,
+	model.OrderStatusPreparing,
+	model.OrderStatusPickedUp,
+	model.OrderStatusDelivered,
+	model.OrderStatusCancelled,
+	model.OrderStatusRejected,
+}
+
+func TestCanTransitionOrderStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		from model.OrderStatus
+		to   model.OrderStatus
+		want bool
+	}{
+		{name: "confirm draft", from: model.OrderStatusDraft, to: model.OrderStatusCreated, want: true},
+		{name: "accept", from: model.OrderStatusCreated, to: model.OrderStatusAccepted, want: true},
+		{name: "reject", from: model.OrderStatusCreated, to: model.OrderStatusRejected, want: true},
+		{name: "cancel created", from: model.OrderStatusCreated, to: model.OrderStatusCancelled, want: true},
+		{name: "prepare", from: model.OrderStatusAccepted, to: model.OrderStatusPreparing, want: true},
+		{name: "cancel accepted", from: model.OrderStatusAccepted, to: model.OrderStatusCancelled, want: true},
+		{name: "pick up", from: model.OrderStatusPreparing, to: model.OrderStatusPickedUp, want: true},
+		{name: "cancel preparing", from: model.OrderStatusPreparing, to: model.OrderStatusCancelled, want: true},
+		{name: "deliver", from: model.OrderStatusPickedUp, to: model.OrderStatusDelivered, want: true},
+
+		{name: "cancel draft", from: model.OrderStatusDraft, to: model.OrderStatusCancelled, want: false},
+		{name: "skip accept", from: model.OrderStatusCreated, to: model.OrderStatusPreparing, want: false},
+		{name: "skip pick up", from: model.OrderStatusPreparing, to: model.OrderStatusDelivered, want: false},
+		{name: "cancel picked up", from: model.OrderStatusPickedUp, to: model.OrderStatusCancelled, want: false},
+		{name: "back to created", from: model.OrderStatusAccepted, to: model.OrderStatusCreated, want: false},
+		{name: "same status", from: model.OrderStatusCreated, to: model.OrderStatusCreated, want: false},
+		{name: "unknown status", from: model.OrderStatus("UNKNOWN"), to: model.OrderStatusCreated, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanTransitionOrderStatus(tt.from, tt.to); got != tt.want {
+				t.Errorf("CanTransitionOrderStatus(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanTransitionOrderStatusFinal(t *testing.T) {
+	for _, final := range []model.OrderStatus{model.OrderStatusDelivered, model.OrderStatusCancelled, model.OrderStatusRejected} {
+		for _, to := range allOrderStatuses {
+			if CanTransitionOrderStatus(final, to) {
+				t.Errorf("CanTransitionOrderStatus(%s, %s) = true, want final status", final, to)
+			}
+		}
+	}
+}
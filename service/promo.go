@@ -0,0 +1,178 @@
+package service
+
+import (
+	"beli-mang/model"
+	cerr "beli-mang/pkg/customErr"
+	"beli-mang/repo"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type PromoService interface {
+	CreatePromoCode(ctx context.Context, request model.CreatePromoCodeRequest, createdBy uuid.UUID) (model.PromoCode, error)
+	GetPromoCodes(ctx context.Context, params model.PromoCodeParams) ([]model.PromoCode, error)
+}
+
+type promoSvc struct {
+	repo repo.PromoRepository
+}
+
+func NewPromoService(repo repo.PromoRepository) PromoService {
+	return &promoSvc{repo: repo}
+}
+
+func (s *promoSvc) CreatePromoCode(ctx context.Context, request model.CreatePromoCodeRequest, createdBy uuid.UUID) (promo model.PromoCode, err error) {
+	now := time.Now()
+	promo = model.PromoCode{
+		ID:                 uuid.New(),
+		Code:               normalizePromoCode(request.Code),
+		DiscountType:       model.PromoDiscountType(request.DiscountType),
+		DiscountValue:      request.DiscountValue,
+		MaxDiscount:        request.MaxDiscount,
+		MinSpend:           request.MinSpend,
+		PerUserLimit:       request.PerUserLimit,
+		UsageLimit:         request.UsageLimit,
+		ValidFrom:          now,
+		ValidTo:            request.ValidTo,
+		MerchantIds:        pq.StringArray{},
+		MerchantCategories: pq.StringArray(request.MerchantCategories),
+		ItemCategories:     pq.StringArray(request.ItemCategories),
+		CreatedBy:          &createdBy,
+		CreatedAt:          now,
+	}
+	if request.ValidFrom != nil {
+		promo.ValidFrom = *request.ValidFrom
+	}
+	if promo.MerchantCategories == nil {
+		promo.MerchantCategories = pq.StringArray{}
+	}
+	if promo.ItemCategories == nil {
+		promo.ItemCategories = pq.StringArray{}
+	}
+	// store the ids in their canonical form so they match merchant.ID.String()
+	for _, id := range request.MerchantIds {
+		merchantId, _ := uuid.Parse(id)
+		promo.MerchantIds = append(promo.MerchantIds, merchantId.String())
+	}
+
+	if promo.DiscountType == model.PromoDiscountPercentage && promo.DiscountValue > 100 {
+		return promo, cerr.New(http.StatusBadRequest, "percentage discount can't be more than 100")
+	}
+	if promo.DiscountType == model.PromoDiscountFixed && promo.MaxDiscount != nil {
+		return promo, cerr.New(http.StatusBadRequest, "maxDiscount only apply to percentage discount")
+	}
+	if !promo.ValidTo.After(promo.ValidFrom) {
+		return promo, cerr.New(http.StatusBadRequest, "validTo must be after validFrom")
+	}
+	if !promo.ValidTo.After(now) {
+		return promo, cerr.New(http.StatusBadRequest, "validTo must be in the future")
+	}
+
+	_, err = s.repo.GetPromoCodeByCode(ctx, promo.Code)
+	if err == nil {
+		return promo, cerr.New(http.StatusConflict, "promo code already exist")
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return promo, err
+	}
+
+	err = s.repo.CreatePromoCode(ctx, promo)
+	return promo, err
+}
+
+func (s *promoSvc) GetPromoCodes(ctx context.Context, params model.PromoCodeParams) ([]model.PromoCode, error) {
+	params.Code = normalizePromoCode(params.Code)
+	return s.repo.GetPromoCodes(ctx, params)
+}
+
+// normalizePromoCode make the code lookup case insensitive, codes are stored upper case
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// applyPromo calculate the discount of the promo on the order, the discount is only given on the items
+// in the promo scope and split between the legs by their eligible subtotal.
+// reason is not empty when the order doesn't qualify for the promo.
+func applyPromo(promo model.PromoCode, detail model.OrderDetail) (applied model.AppliedPromo, reason string) {
+	breakdown := make([]model.PromoDiscountLeg, 0, len(detail))
+	eligible := 0
+	for _, leg := range detail {
+		legEligible := 0
+		for _, item := range leg.Items {
+			if promo.AppliesTo(leg.Merchant, item) {
+				legEligible += item.UnitPrice() * item.Quantity
+			}
+		}
+		breakdown = append(breakdown, model.PromoDiscountLeg{
+			MerchantId:       leg.Merchant.ID,
+			EligibleSubtotal: legEligible,
+		})
+		eligible += legEligible
+	}
+	if eligible == 0 {
+		return applied, "promo code doesn't apply to any item of the order"
+	}
+	if eligible < promo.MinSpend {
+		return applied, "order doesn't reach the minimum spend of the promo code"
+	}
+
+	discount := promo.DiscountValue
+	if promo.DiscountType == model.PromoDiscountPercentage {
+		discount = eligible * promo.DiscountValue / 100
+		if promo.MaxDiscount != nil && discount > *promo.MaxDiscount {
+			discount = *promo.MaxDiscount
+		}
+	}
+	if discount > eligible {
+		discount = eligible
+	}
+
+	// split proportionally, the rounding remainder goes to the last eligible leg
+	remaining := discount
+	last := -1
+	for i := range breakdown {
+		if breakdown[i].EligibleSubtotal == 0 {
+			continue
+		}
+		breakdown[i].Discount = discount * breakdown[i].EligibleSubtotal / eligible
+		remaining -= breakdown[i].Discount
+		last = i
+	}
+	breakdown[last].Discount += remaining
+
+	return model.AppliedPromo{
+		PromoCodeId:    promo.ID,
+		Code:           promo.Code,
+		DiscountAmount: discount,
+		Breakdown:      breakdown,
+	}, ""
+}
+
+// checkPromoUsable make sure the promo is in its validity window and the user still can use it,
+// q is the tx to count the usage in or nil to use the db
+func (s *purchaseSvc) checkPromoUsable(ctx context.Context, q sqlx.QueryerContext, promo model.PromoCode, userId uuid.UUID, t time.Time) (reason string, err error) {
+	if !promo.IsValidAt(t) {
+		return "promo code is not valid at this time", nil
+	}
+	if promo.IsExhausted() {
+		return "promo code has reached its usage limit", nil
+	}
+	if promo.PerUserLimit != nil {
+		used, err := s.promoRepo.CountUserUsage(ctx, q, promo.ID, userId)
+		if err != nil {
+			return "", err
+		}
+		if used >= *promo.PerUserLimit {
+			return "promo code has reached its usage limit for this user", nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"beli-mang/model"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+var (
+	promoMerchantA = model.Merchant{ID: uuid.MustParse("00000000-0000-0000-0000-00000000000a"), Category: model.SmallRestaurant}
+	promoMerchantB = model.Merchant{ID: uuid.MustParse("00000000-0000-0000-0000-00000000000b"), Category: model.LargeRestaurant}
+	promoMerchantC = model.Merchant{ID: uuid.MustParse("00000000-0000-0000-0000-00000000000c"), Category: model.SmallRestaurant}
+)
+
+func promoLeg(merchant model.Merchant, items ...model.BoughtItem) model.OrderData {
+	return model.OrderData{Merchant: merchant, Items: items}
+}
+
+func promoItem(category string, price, quantity int) model.BoughtItem {
+	return model.BoughtItem{ProductCategory: category, Price: price, Quantity: quantity}
+}
+
+func TestApplyPromo(t *testing.T) {
+	maxDiscount := 3000
+	tests := []struct {
+		name   string
+		promo  model.PromoCode
+		detail model.OrderDetail
+		// wantDiscounts is the discount of every leg, nil when the order doesn't qualify
+		wantDiscounts []int
+		wantReason    string
+	}{
+		{
+			name:          "percentage",
+			promo:         model.PromoCode{DiscountType: model.PromoDiscountPercentage, DiscountValue: 10},
+			detail:        model.OrderDetail{promoLeg(promoMerchantA, promoItem("Food", 25000, 2))},
+			wantDiscounts: []int{5000},
+		},
+		{
+			name:          "percentage capped by max discount",
+			promo:         model.PromoCode{DiscountType: model.PromoDiscountPercentage, DiscountValue: 10, MaxDiscount: &maxDiscount},
+			detail:        model.OrderDetail{promoLeg(promoMerchantA, promoItem("Food", 25000, 2))},
+			wantDiscounts: []int{3000},
+		},
+		{
+			name:          "max discount doesn't cap fixed discount",
+			promo:         model.PromoCode{DiscountType: model.PromoDiscountFixed, DiscountValue: 4000, MaxDiscount: &maxDiscount},
+			detail:        model.OrderDetail{promoLeg(promoMerchantA, promoItem("Food", 25000, 2))},
+			wantDiscounts: []int{4000},
+		},
+		{
+			name:          "fixed discount capped by eligible subtotal",
+			promo:         model.PromoCode{DiscountType: model.PromoDiscountFixed, DiscountValue: 10000},
+			detail:        model.OrderDetail{promoLeg(promoMerchantA, promoItem("Food", 3000, 2))},
+			wantDiscounts: []int{6000},
+		},
+		{
+			name:  "options count in eligible subtotal",
+			promo: model.PromoCode{DiscountType: model.PromoDiscountPercentage, DiscountValue: 50},
+			detail: model.OrderDetail{promoLeg(promoMerchantA, model.BoughtItem{
+				ProductCategory: "Food",
+				Price:           1000,
+				Quantity:        2,
+				Options:         []model.BoughtItemOption{{PriceDelta: 500}},
+			})},
+			wantDiscounts: []int{1500},
+		},
+		{
+			name:  "split by eligible subtotal",
+			promo: model.PromoCode{DiscountType: model.PromoDiscountFixed, DiscountValue: 3000},
+			detail: model.OrderDetail{
+				promoLeg(promoMerchantA, promoItem("Food", 10000, 1)),
+				promoLeg(promoMerchantB, promoItem("Food", 20000, 1)),
+			},
+			wantDiscounts: []int{1000, 2000},
+		},
+		{
+			name:  "remainder goes to the last eligible leg",
+			promo: model.PromoCode{DiscountType: model.PromoDiscountFixed, DiscountValue: 100, ItemCategories: []string{"Food"}},
+			detail: model.OrderDetail{
+				promoLeg(promoMerchantA, promoItem("Food", 1000, 1)),
+				promoLeg(promoMerchantB, promoItem("Food", 1000, 1)),
+				promoLeg(promoMerchantC, promoItem("Food", 1000, 1), promoItem("Beverage", 5000, 1)),
+			},
+			wantDiscounts: []int{33, 33, 34},
+		},
+		{
+			name:  "remainder skips a later leg out of scope",
+			promo: model.PromoCode{DiscountType: model.PromoDiscountFixed, DiscountValue: 100, MerchantIds: []string{promoMerchantA.ID.String(), promoMerchantB.ID.String()}},
+			detail: model.OrderDetail{
+				promoLeg(promoMerchantA, promoItem("Food", 1000, 1)),
+				promoLeg(promoMerchantB, promoItem("Food", 2000, 1)),
+				promoLeg(promoMerchantC, promoItem("Food", 1000, 1)),
+			},
+			wantDiscounts: []int{33, 67, 0},
+		},
+		{
+			name:  "merchant category scope",
+			promo: model.PromoCode{DiscountType: model.PromoDiscountPercentage, DiscountValue: 10, MerchantCategories: []string{string(model.LargeRestaurant)}},
+			detail: model.OrderDetail{
+				promoLeg(promoMerchantA, promoItem("Food", 10000, 1)),
+				promoLeg(promoMerchantB, promoItem("Food", 10000, 1)),
+			},
+			wantDiscounts: []int{0, 1000},
+		},
+		{
+			name:  "minimum spend counts only eligible items",
+			promo: model.PromoCode{DiscountType: model.PromoDiscountFixed, DiscountValue: 1000, MinSpend: 15000, ItemCategories: []string{"Beverage"}},
+			detail: model.OrderDetail{
+				promoLeg(promoMerchantA, promoItem("Beverage", 10000, 1), promoItem("Food", 50000, 1)),
+			},
+			wantReason: "order doesn't reach the minimum spend of the promo code",
+		},
+		{
+			name:          "minimum spend reached",
+			promo:         model.PromoCode{DiscountType: model.PromoDiscountFixed, DiscountValue: 1000, MinSpend: 15000},
+			detail:        model.OrderDetail{promoLeg(promoMerchantA, promoItem("Food", 5000, 3))},
+			wantDiscounts: []int{1000},
+		},
+		{
+			name:       "nothing in scope",
+			promo:      model.PromoCode{DiscountType: model.PromoDiscountFixed, DiscountValue: 1000, ItemCategories: []string{"Snack"}},
+			detail:     model.OrderDetail{promoLeg(promoMerchantA, promoItem("Food", 5000, 3))},
+			wantReason: "promo code doesn't apply to any item of the order",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applied, reason := applyPromo(tt.promo, tt.detail)
+			if reason != tt.wantReason {
+				t.Fatalf("applyPromo() reason = %q, want %q", reason, tt.wantReason)
+			}
+			if tt.wantDiscounts == nil {
+				return
+			}
+			if len(applied.Breakdown) != len(tt.detail) {
+				t.Fatalf("breakdown = %+v, want a leg per merchant", applied.Breakdown)
+			}
+			total := 0
+			for i, leg := range applied.Breakdown {
+				if leg.MerchantId != tt.detail[i].Merchant.ID {
+					t.Errorf("breakdown[%d] merchant = %s, want %s", i, leg.MerchantId, tt.detail[i].Merchant.ID)
+				}
+				if leg.Discount != tt.wantDiscounts[i] {
+					t.Errorf("breakdown[%d] discount = %d, want %d", i, leg.Discount, tt.wantDiscounts[i])
+				}
+				total += leg.Discount
+			}
+			if applied.DiscountAmount != total {
+				t.Errorf("DiscountAmount = %d, want the breakdown total %d", applied.DiscountAmount, total)
+			}
+		})
+	}
+}
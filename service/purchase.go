@@ -1,18 +1,21 @@
 package service
 
 import (
+	"beli-mang/config"
 	"beli-mang/model"
 	cerr "beli-mang/pkg/customErr"
 	"beli-mang/pkg/panics"
 	"beli-mang/repo"
 	"context"
+	"database/sql"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 	"go.uber.org/zap"
-	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -23,19 +26,36 @@ type PurchaseService interface {
 	ConfirmOrder(ctx context.Context, request model.ConfirmOrderRequest) (response model.ConfirmOrderResponse, err error)
 	GetUserOrders(ctx context.Context, request model.UserOrdersParams) (response model.GetUserOrdersResponse, err error)
 	GetNearbyMerchant(ctx context.Context, params model.GetMerchantParams, lat, long string) (listMerchant []model.GetNearbyMerchantData, meta model.MetaData, err error)
+	UpdateOrderStatus(ctx context.Context, request model.UpdateOrderStatusRequest) (response model.UpdateOrderStatusResponse, err error)
+	GetMerchantOrders(ctx context.Context, params model.MerchantOrdersParams) (response model.GetMerchantOrdersResponse, err error)
+	AcceptMerchantOrder(ctx context.Context, request model.MerchantOrderActionRequest) (response model.MerchantOrderActionResponse, err error)
+	RejectMerchantOrder(ctx context.Context, request model.MerchantOrderActionRequest) (response model.MerchantOrderActionResponse, err error)
+	CancelUserOrder(ctx context.Context, request model.CancelOrderRequest) (response model.CancelOrderResponse, err error)
+	CancelMerchantOrder(ctx context.Context, request model.CancelOrderRequest) (response model.CancelOrderResponse, err error)
+	GetOrderDetail(ctx context.Context, request model.GetOrderDetailRequest) (response model.GetOrderDetailResponse, err error)
+	Reorder(ctx context.Context, request model.ReorderRequest) (response model.ReorderResponse, err error)
+	PreviewSpeedProfiles(ctx context.Context, request model.SpeedProfilePreviewRequest) (response model.SpeedProfilePreviewResponse, err error)
 }
 
 type purchaseSvc struct {
-	orderRepo    repo.OrderRepository
-	merchantRepo repo.MerchantRepository
-	logger       *zap.Logger
+	cfg             *config.Config
+	orderRepo       repo.OrderRepository
+	merchantRepo    repo.MerchantRepository
+	promoRepo       repo.PromoRepository
+	serviceAreaRepo repo.ServiceAreaRepository
+	router          RoutingProvider
+	logger          *zap.Logger
 }
 
-func NewPurchaseService(orderRepo repo.OrderRepository, merchantRepo repo.MerchantRepository, logger *zap.Logger) PurchaseService {
+func NewPurchaseService(cfg *config.Config, orderRepo repo.OrderRepository, merchantRepo repo.MerchantRepository, promoRepo repo.PromoRepository, serviceAreaRepo repo.ServiceAreaRepository, router RoutingProvider, logger *zap.Logger) PurchaseService {
 	return &purchaseSvc{
-		orderRepo:    orderRepo,
-		merchantRepo: merchantRepo,
-		logger:       logger,
+		cfg:             cfg,
+		orderRepo:       orderRepo,
+		merchantRepo:    merchantRepo,
+		promoRepo:       promoRepo,
+		serviceAreaRepo: serviceAreaRepo,
+		router:          router,
+		logger:          logger,
 	}
 }
 
@@ -51,7 +71,6 @@ func (s *purchaseSvc) EstimateOrders(ctx context.Context, request model.Estimate
 	var itemIds []uuid.UUID
 	var detail model.OrderDetail
 	var merchantIDStartingPoint uuid.UUID
-	mapItemQuantities := make(map[uuid.UUID][]int)
 	for _, order := range request.Orders {
 		merchantId, err := uuid.Parse(order.MerchantId)
 		if err != nil {
@@ -71,7 +90,6 @@ func (s *purchaseSvc) EstimateOrders(ctx context.Context, request model.Estimate
 			if err != nil {
 				return response, cerr.New(http.StatusNotFound, "bad item id")
 			}
-			mapItemQuantities[itemID] = append(mapItemQuantities[itemID], item.Quantity)
 			itemIds = append(itemIds, itemID)
 		}
 	}
@@ -83,6 +101,9 @@ func (s *purchaseSvc) EstimateOrders(ctx context.Context, request model.Estimate
 	// use go routine to get data concurrently
 	var mapMerchant map[uuid.UUID]model.Merchant
 	var mapItems map[uuid.UUID]model.Item
+	var mapSchedules map[uuid.UUID]model.MerchantSchedule
+	var mapGroups map[uuid.UUID][]model.ItemOptionGroup
+	var errSchedules, errGroups error
 	var wg sync.WaitGroup
 
 	wg.Add(1)
@@ -107,19 +128,53 @@ func (s *purchaseSvc) EstimateOrders(ctx context.Context, request model.Estimate
 		}
 	}, func() {})
 
+	wg.Add(1)
+	go panics.CaptureGoroutine(func() {
+		defer wg.Done()
+		// get opening hours to reject closed merchant
+		mapSchedules, errSchedules = s.merchantRepo.GetMerchantScheduleMapByIds(ctx, merchantIds)
+		if errSchedules != nil {
+			s.logger.Error(logPrefix+"failed to get merchant schedule map", zap.Error(errSchedules))
+		}
+	}, func() {})
+
+	wg.Add(1)
+	go panics.CaptureGoroutine(func() {
+		defer wg.Done()
+		// get option groups to price the selected options
+		mapGroups, errGroups = s.merchantRepo.GetItemOptionGroupMapByItemIds(ctx, itemIds)
+		if errGroups != nil {
+			s.logger.Error(logPrefix+"failed to get item option group map", zap.Error(errGroups))
+		}
+	}, func() {})
+
 	wg.Wait()
 
 	if len(mapItems) == 0 || len(mapMerchant) == 0 {
 		return response, cerr.New(http.StatusBadRequest, "invalid items/merchants request")
 	}
-
-	for _, item := range mapItems {
-		for _, qty := range mapItemQuantities[item.Id] {
-			totalPrice += item.Price * qty
-			//fmt.Printf("calculate_total itemId: %s, price: %d, quantity: %d\n", item.Id, item.Price, qty)
+	// a deleted or unknown merchant would be priced without being routed or checked
+	for _, order := range request.Orders {
+		merchantId, _ := uuid.Parse(order.MerchantId)
+		if _, ok := mapMerchant[merchantId]; !ok {
+			return response, cerr.New(http.StatusBadRequest, "merchant "+order.MerchantId+" not found")
 		}
 	}
 
+	if errGroups != nil {
+		return response, errGroups
+	}
+	if itemErrors := validateOrderItems(request.Orders, mapItems, mapGroups); len(itemErrors) > 0 {
+		return response, cerr.NewWithData(http.StatusBadRequest, "some items can't be ordered", itemErrors)
+	}
+
+	if errSchedules != nil {
+		return response, errSchedules
+	}
+	if closed := closedMerchants(request.Orders, mapMerchant, mapSchedules, time.Now()); len(closed) > 0 {
+		return response, cerr.NewWithData(http.StatusBadRequest, "some merchants are closed", closed)
+	}
+
 	var merchantNames []string
 	var merchantCategoriesStr []string
 	var ItemsName []string
@@ -130,52 +185,57 @@ func (s *purchaseSvc) EstimateOrders(ctx context.Context, request model.Estimate
 			bItem := mapItems[itemID].ItemToBoughtItem()
 			ItemsName = append(ItemsName, bItem.Name)
 			bItem.Quantity = item.Quantity
+			bItem.Options, _ = selectItemOptions(mapGroups[itemID], parseOptionIds(item.Options))
 			boughtItems = append(boughtItems, bItem)
 		}
 		merchantId, _ := uuid.Parse(order.MerchantId)
 		merchant := mapMerchant[merchantId]
 		merchantNames = append(merchantNames, merchant.Name)
 		merchantCategoriesStr = append(merchantCategoriesStr, string(merchant.Category))
-		detail = append(detail, model.OrderData{
+		leg := model.OrderData{
 			Merchant:        merchant,
 			IsStartingPoint: order.IsStartingPoint,
 			Items:           boughtItems,
-		})
+		}
+		totalPrice += leg.TotalPrice()
+		detail = append(detail, leg)
 	}
 	detailRaw, err := json.Marshal(detail)
 	if err != nil {
 		return response, err
 	}
 
+	// promo is only checked here, its usage is counted when the order is confirmed
+	var promo *model.AppliedPromo
+	if request.PromoCode != "" {
+		promo, err = s.estimatePromo(ctx, request.PromoCode, request.UserId, detail)
+		if err != nil {
+			return response, err
+		}
+	}
+
 	// calculate distance by tsp
 	end := model.Point{
 		Lat: request.UserLocation.Lat,
 		Lon: request.UserLocation.Long,
 	}
-	// compose point from merchantLocation
-	points := make([]model.Point, len(mapMerchant))
-	for _, merchant := range mapMerchant {
-		if merchant.ID == merchantIDStartingPoint {
-			points = append([]model.Point{{
-				Lat: merchant.Location.Lat,
-				Lon: merchant.Location.Long,
-			}}, points...)
-			continue
-		}
-		points = append(points, model.Point{
-			Lat: merchant.Location.Lat,
-			Lon: merchant.Location.Long,
-		})
-
+	// the starting point merchant goes first, the route always start from it and end at the user
+	merchants := routeMerchants(detail)
+	if len(merchants) == 0 || merchants[0].ID != merchantIDStartingPoint {
+		return response, cerr.New(http.StatusBadRequest, "invalid items/merchants request")
 	}
 
-	// check first distance, points will always not null.
-	p1 := points[0]
-	p2 := end
-	if distance := haversineDistance(p1.Lat, p1.Lon, p2.Lat, p2.Lon); distance > model.MaxDistanceFromStartingPoint {
-		return response, cerr.New(http.StatusBadRequest, fmt.Sprintf("far from merchant starting point with distance %.2f km", distance))
+	// check every merchant deliver to the user
+	if err = s.checkDeliveryArea(ctx, merchants, end); err != nil {
+		return response, err
+	}
+	now := time.Now()
+	start := model.Point{Lat: merchants[0].Location.Lat, Lon: merchants[0].Location.Long}
+	traffic := trafficAt(s.cfg.SpeedProfiles, start, now)
+	route, visitOrder, wait, err := s.planRoute(ctx, merchants, merchantsPrepTime(detail), end, traffic)
+	if err != nil {
+		return response, err
 	}
-	estTime := EstimateDeliveryTimeTSP(points, end)
 
 	// tx start
 	tx, err := s.orderRepo.BeginTx(ctx)
@@ -184,7 +244,7 @@ func (s *purchaseSvc) EstimateOrders(ctx context.Context, request model.Estimate
 	}
 	defer func() {
 		if err != nil {
-			err = tx.Rollback()
+			_ = tx.Rollback()
 		} else {
 			err = tx.Commit()
 		}
@@ -203,6 +263,7 @@ func (s *purchaseSvc) EstimateOrders(ctx context.Context, request model.Estimate
 		UserID:             request.UserId, // buyerId
 		UserLatitude:       request.UserLocation.Lat,
 		UserLongitude:      request.UserLocation.Long,
+		CreatedAt:          now,
 	}
 	_, err = s.orderRepo.Create(ctx, tx, orderData)
 	if err != nil {
@@ -211,11 +272,28 @@ func (s *purchaseSvc) EstimateOrders(ctx context.Context, request model.Estimate
 
 	// submit calculation
 	calculatedData := model.CalculatedEstimate{
-		TotalPrice:                     totalPrice,
-		EstimatedDeliveryTimeInMinutes: int(math.Round(estTime.Minutes())),
-		CalculatedEstimateId:           uuid.New(),
-		OrderId:                        orderId,
-		CreatedAt:                      time.Now(),
+		SubtotalPrice:        totalPrice,
+		TotalPrice:           totalPrice,
+		CalculatedEstimateId: uuid.New(),
+		OrderId:              orderId,
+		CreatedAt:            now,
+		UserId:               request.UserId,
+		ExpiresAt:            now.Add(s.cfg.EstimateTTL),
+		DiscountBreakdownRaw: json.RawMessage(`[]`),
+		EstimateFees:         calculateFees(s.cfg.Fee, traffic, route.DistanceKm, len(merchants), totalPrice),
+		RouteDistanceInKm:    route.DistanceKm,
+		VisitOrder:           visitOrder,
+	}
+	calculatedData.SetDeliveryTime(wait, route.Duration)
+	calculatedData.TotalPrice += calculatedData.EstimateFees.Total()
+	if promo != nil {
+		calculatedData.PromoCodeId = &promo.PromoCodeId
+		calculatedData.DiscountAmount = promo.DiscountAmount
+		calculatedData.TotalPrice -= promo.DiscountAmount
+		calculatedData.DiscountBreakdownRaw, err = json.Marshal(promo.Breakdown)
+		if err != nil {
+			return response, err
+		}
 	}
 	_, err = s.orderRepo.InsertCalculation(ctx, tx, calculatedData)
 	if err != nil {
@@ -224,18 +302,220 @@ func (s *purchaseSvc) EstimateOrders(ctx context.Context, request model.Estimate
 
 	// submit order
 	return model.EstimateOrdersResponse{
+		SubtotalPrice:                  calculatedData.SubtotalPrice,
 		TotalPrice:                     calculatedData.TotalPrice,
 		EstimatedDeliveryTimeInMinutes: calculatedData.EstimatedDeliveryTimeInMinutes,
 		CalculatedEstimateId:           calculatedData.CalculatedEstimateId,
+		ExpiresAt:                      calculatedData.ExpiresAt,
+		Promo:                          promo,
+		PriceBreakdown:                 calculatedData.PriceBreakdown(),
+		VisitOrder:                     calculatedData.VisitOrder,
+		DeliveryTimeBreakdown:          calculatedData.DeliveryTimeBreakdown(),
+		Traffic:                        traffic,
 	}, nil
 }
 
+// routeMerchants returns the merchants to deliver from without duplicate and rejected legs,
+// the starting point merchant is first, or the first remaining merchant when it is rejected.
+func routeMerchants(detail model.OrderDetail) []model.Merchant {
+	var merchants []model.Merchant
+	seen := make(map[uuid.UUID]bool, len(detail))
+	for _, leg := range detail {
+		if leg.Status == model.OrderStatusRejected || leg.Merchant.ID == uuid.Nil || seen[leg.Merchant.ID] {
+			continue
+		}
+		seen[leg.Merchant.ID] = true
+		if leg.IsStartingPoint {
+			merchants = append([]model.Merchant{leg.Merchant}, merchants...)
+			continue
+		}
+		merchants = append(merchants, leg.Merchant)
+	}
+	return merchants
+}
+
+// merchantsPrepTime returns how long every merchant needs to prepare its legs, rejected legs are not prepared
+func merchantsPrepTime(detail model.OrderDetail) map[uuid.UUID]time.Duration {
+	prepTimes := make(map[uuid.UUID]time.Duration, len(detail))
+	for _, leg := range detail {
+		if leg.Status == model.OrderStatusRejected {
+			continue
+		}
+		if prepTime := leg.PrepTime(); prepTime > prepTimes[leg.Merchant.ID] {
+			prepTimes[leg.Merchant.ID] = prepTime
+		}
+	}
+	return prepTimes
+}
+
+// planRoute solve the order to visit the merchants from merchants[0] to the user and route it in the traffic,
+// visitOrder is the merchant ids in that order and wait is the courier waiting for the food on the way
+func (s *purchaseSvc) planRoute(ctx context.Context, merchants []model.Merchant, prepTimes map[uuid.UUID]time.Duration, end model.Point, traffic model.Traffic) (route model.Route, visitOrder pq.StringArray, wait time.Duration, err error) {
+	points := make([]model.Point, 0, len(merchants))
+	for _, merchant := range merchants {
+		points = append(points, model.Point{Lat: merchant.Location.Lat, Lon: merchant.Location.Long})
+	}
+
+	order := VisitOrder(points, end)
+	waypoints := make([]model.Point, 0, len(order)+1)
+	visitOrder = make(pq.StringArray, 0, len(order))
+	readyIn := make([]time.Duration, 0, len(order))
+	for _, i := range order {
+		waypoints = append(waypoints, points[i])
+		visitOrder = append(visitOrder, merchants[i].ID.String())
+		readyIn = append(readyIn, prepTimes[merchants[i].ID])
+	}
+
+	route, err = s.router.Route(ctx, append(waypoints, end))
+	if err != nil {
+		return route, visitOrder, 0, err
+	}
+	route = applyTraffic(route, traffic)
+	return route, visitOrder, courierWaitTime(readyIn, route.LegDurations), nil
+}
+
+// estimatePromo returns the promo applied to the order detail, the error has status 400 when it can't be used
+func (s *purchaseSvc) estimatePromo(ctx context.Context, code string, userId uuid.UUID, detail model.OrderDetail) (*model.AppliedPromo, error) {
+	promo, err := s.promoRepo.GetPromoCodeByCode(ctx, normalizePromoCode(code))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, cerr.New(http.StatusBadRequest, "promo code not found")
+		}
+		return nil, err
+	}
+	reason, err := s.checkPromoUsable(ctx, nil, promo, userId, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if reason != "" {
+		return nil, cerr.New(http.StatusBadRequest, reason)
+	}
+	applied, reason := applyPromo(promo, detail)
+	if reason != "" {
+		return nil, cerr.New(http.StatusBadRequest, reason)
+	}
+	return &applied, nil
+}
+
+// validateOrderItems make sure every requested item exist in its merchant, is available to order
+// and its selected options follow the option group rules
+func validateOrderItems(orders []model.OrderRequest, mapItems map[uuid.UUID]model.Item, mapGroups map[uuid.UUID][]model.ItemOptionGroup) []model.ItemError {
+	var itemErrors []model.ItemError
+	for _, order := range orders {
+		merchantId, _ := uuid.Parse(order.MerchantId)
+		for _, reqItem := range order.Items {
+			itemID, _ := uuid.Parse(reqItem.ItemId)
+			item, ok := mapItems[itemID]
+			if !ok || item.MerchantId != merchantId {
+				itemErrors = append(itemErrors, model.ItemError{
+					MerchantId: order.MerchantId,
+					ItemId:     reqItem.ItemId,
+					Reason:     "item not found",
+				})
+				continue
+			}
+			if !item.IsAvailable {
+				itemErrors = append(itemErrors, model.ItemError{
+					MerchantId: order.MerchantId,
+					ItemId:     reqItem.ItemId,
+					Name:       item.Name,
+					Reason:     "item is not available",
+				})
+				continue
+			}
+			if _, reason := selectItemOptions(mapGroups[itemID], parseOptionIds(reqItem.Options)); reason != "" {
+				itemErrors = append(itemErrors, model.ItemError{
+					MerchantId: order.MerchantId,
+					ItemId:     reqItem.ItemId,
+					Name:       item.Name,
+					Reason:     reason,
+				})
+			}
+		}
+	}
+	return itemErrors
+}
+
+// closedMerchants returns the merchants of the orders that are outside their opening hours at t
+func closedMerchants(orders []model.OrderRequest, mapMerchant map[uuid.UUID]model.Merchant, mapSchedules map[uuid.UUID]model.MerchantSchedule, t time.Time) []model.ClosedMerchant {
+	var closed []model.ClosedMerchant
+	for _, order := range orders {
+		merchantId, _ := uuid.Parse(order.MerchantId)
+		schedule, ok := mapSchedules[merchantId]
+		if !ok || schedule.IsOpenAt(t) {
+			continue
+		}
+		closed = append(closed, model.ClosedMerchant{
+			MerchantId: merchantId,
+			Name:       mapMerchant[merchantId].Name,
+		})
+	}
+	return closed
+}
+
 func (s *purchaseSvc) ConfirmOrder(ctx context.Context, request model.ConfirmOrderRequest) (response model.ConfirmOrderResponse, err error) {
-	calculatedData, err := s.orderRepo.GetCalculatedEstimateById(ctx, request.CalculatedEstimateId)
+	tx, err := s.orderRepo.BeginTx(ctx)
+	if err != nil {
+		return response, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	// lock the estimate, concurrent confirm of the same estimate will wait here
+	calculatedData, err := s.orderRepo.GetCalculatedEstimateByIdForUpdate(ctx, tx, request.CalculatedEstimateId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return response, cerr.New(http.StatusNotFound, "calculated estimate not found")
+		}
+		return response, err
+	}
+	// estimate of another user is treated as not found, so the id can't be probed
+	if calculatedData.UserId != request.UserId {
+		return response, cerr.New(http.StatusNotFound, "calculated estimate not found")
+	}
+	if calculatedData.ConfirmedAt != nil {
+		return response, cerr.New(http.StatusConflict, "calculated estimate already confirmed")
+	}
+	now := time.Now()
+	if now.After(calculatedData.ExpiresAt) {
+		return response, cerr.New(http.StatusGone, "calculated estimate expired, please re-estimate the order")
+	}
+
+	// the estimate is a snapshot, make sure the catalog still match it before the order is created
+	order, err := s.orderRepo.GetOrderById(ctx, calculatedData.OrderId)
 	if err != nil {
-		return response, cerr.New(http.StatusNotFound, err.Error())
+		return response, err
 	}
-	err = s.orderRepo.UpdateStatus(ctx, calculatedData.OrderId, model.OrderStatusCreated)
+	changes, err := s.revalidateOrderDetail(ctx, order.Detail)
+	if err != nil {
+		return response, err
+	}
+	if !changes.IsEmpty() {
+		return response, cerr.NewWithData(http.StatusConflict, "order items have changed since estimated, please re-estimate the order", changes)
+	}
+
+	err = s.orderRepo.ConfirmCalculatedEstimate(ctx, tx, calculatedData.CalculatedEstimateId, now)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return response, cerr.New(http.StatusConflict, "calculated estimate already confirmed")
+		}
+		return response, err
+	}
+
+	// count the promo usage in the same tx, the promo row lock keep the limits exact under concurrent confirm
+	if calculatedData.PromoCodeId != nil {
+		err = s.usePromo(ctx, tx, calculatedData, now)
+		if err != nil {
+			return response, err
+		}
+	}
+
+	err = s.transitionOrderStatus(ctx, tx, calculatedData.OrderId, model.OrderStatusDraft, model.OrderStatusCreated, request.UserId)
 	if err != nil {
 		return response, err
 	}
@@ -243,9 +523,32 @@ func (s *purchaseSvc) ConfirmOrder(ctx context.Context, request model.ConfirmOrd
 	return response, nil
 }
 
+// usePromo recheck the promo of the estimate and record its usage
+func (s *purchaseSvc) usePromo(ctx context.Context, tx *sqlx.Tx, calculatedData model.CalculatedEstimate, now time.Time) error {
+	promo, err := s.promoRepo.GetPromoCodeByIdForUpdate(ctx, tx, *calculatedData.PromoCodeId)
+	if err != nil {
+		return err
+	}
+	reason, err := s.checkPromoUsable(ctx, tx, promo, calculatedData.UserId, now)
+	if err != nil {
+		return err
+	}
+	if reason != "" {
+		return cerr.New(http.StatusConflict, reason+", please re-estimate the order")
+	}
+	return s.promoRepo.InsertUsage(ctx, tx, model.PromoCodeUsage{
+		ID:                   uuid.New(),
+		PromoCodeId:          promo.ID,
+		UserId:               calculatedData.UserId,
+		OrderId:              calculatedData.OrderId,
+		CalculatedEstimateId: calculatedData.CalculatedEstimateId,
+		Discount:             calculatedData.DiscountAmount,
+		CreatedAt:            now,
+	})
+}
+
 func (s *purchaseSvc) GetUserOrders(ctx context.Context, request model.UserOrdersParams) (response model.GetUserOrdersResponse, err error) {
 	// get userOrder
-	request.Status = model.OrderStatusCreated
 	response = model.GetUserOrdersResponse{}
 	listData, err := s.orderRepo.GetUserOrders(ctx, request)
 	if err != nil {
@@ -260,10 +563,49 @@ func (s *purchaseSvc) GetUserOrders(ctx context.Context, request model.UserOrder
 }
 
 func (s *purchaseSvc) GetNearbyMerchant(ctx context.Context, params model.GetMerchantParams, lat, long string) (listMerchant []model.GetNearbyMerchantData, meta model.MetaData, err error) {
+	// nothing is delivered outside the service areas, and only merchants of the user area deliver to the user
+	areas, err := loadServiceAreas(ctx, s.serviceAreaRepo)
+	if err != nil {
+		return
+	}
+	userLat, _ := strconv.ParseFloat(lat, 64)
+	userLong, _ := strconv.ParseFloat(long, 64)
+	area, ok := areas.at(userLat, userLong)
+	if !ok {
+		return []model.GetNearbyMerchantData{}, model.MetaData{Offset: params.Offset, Limit: params.Limit}, nil
+	}
+
+	params.DefaultDeliveryRadiusKm = s.cfg.DefaultDeliveryRadiusKm
+	params.ServiceArea = area
 	listMerchant, meta, err = s.orderRepo.GetNearbyMerchant(ctx, params, lat, long)
 	if err != nil {
 		return
 	}
 
+	merchantIds := make([]uuid.UUID, 0, len(listMerchant))
+	var itemIds []uuid.UUID
+	for _, m := range listMerchant {
+		merchantIds = append(merchantIds, m.Merchant.ID)
+		for _, item := range m.Items {
+			itemIds = append(itemIds, item.Id)
+		}
+	}
+	schedules, err := s.merchantRepo.GetMerchantScheduleMapByIds(ctx, merchantIds)
+	if err != nil {
+		return
+	}
+	mapGroups, err := s.merchantRepo.GetItemOptionGroupMapByItemIds(ctx, itemIds)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	for i, m := range listMerchant {
+		schedule, ok := schedules[m.Merchant.ID]
+		listMerchant[i].IsOpen = !ok || schedule.IsOpenAt(now)
+		for j, item := range m.Items {
+			listMerchant[i].Items[j].OptionGroups = mapGroups[item.Id]
+		}
+	}
+
 	return listMerchant, meta, nil
 }
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"beli-mang/model"
+	cerr "beli-mang/pkg/customErr"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// Reorder build a new estimate from the items of a previous order,
+// merchants and items that no longer exist or are sold out are skipped and reported back,
+// the selected options of the previous order are kept.
+func (s *purchaseSvc) Reorder(ctx context.Context, request model.ReorderRequest) (response model.ReorderResponse, err error) {
+	response.MissingMerchants = []model.MissingMerchant{}
+	response.MissingItems = []model.MissingItem{}
+	response.UnavailableItems = []model.MissingItem{}
+
+	order, err := s.orderRepo.GetOrderById(ctx, request.OrderId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return response, cerr.New(http.StatusNotFound, "order not found")
+		}
+		return response, err
+	}
+	if order.OrderStatus == model.OrderStatusDraft || order.UserID != request.UserId {
+		return response, cerr.New(http.StatusNotFound, "order not found")
+	}
+
+	var merchantIds, itemIds []uuid.UUID
+	for _, leg := range order.Detail {
+		merchantIds = append(merchantIds, leg.Merchant.ID)
+		for _, item := range leg.Items {
+			itemIds = append(itemIds, item.ItemId)
+		}
+	}
+
+	mapMerchant, err := s.merchantRepo.GetMerchantMapByIds(ctx, merchantIds)
+	if err != nil {
+		return response, err
+	}
+	mapItems, err := s.merchantRepo.GetMerchantItemMapByIds(ctx, itemIds)
+	if err != nil {
+		return response, err
+	}
+	mapGroups, err := s.merchantRepo.GetItemOptionGroupMapByItemIds(ctx, itemIds)
+	if err != nil {
+		return response, err
+	}
+
+	estimateRequest := model.EstimateOrdersRequest{
+		UserId: request.UserId,
+		UserLocation: model.UserLocation{
+			Lat:  order.UserLatitude,
+			Long: order.UserLongitude,
+		},
+	}
+	if request.UserLocation != nil {
+		estimateRequest.UserLocation = *request.UserLocation
+	}
+
+	hasStartingPoint := false
+	for _, leg := range order.Detail {
+		if _, ok := mapMerchant[leg.Merchant.ID]; !ok {
+			response.MissingMerchants = append(response.MissingMerchants, model.MissingMerchant{
+				MerchantId: leg.Merchant.ID,
+				Name:       leg.Merchant.Name,
+			})
+			continue
+		}
+
+		orderRequest := model.OrderRequest{
+			MerchantId:      leg.Merchant.ID.String(),
+			IsStartingPoint: leg.IsStartingPoint,
+		}
+		for _, item := range leg.Items {
+			if current, ok := mapItems[item.ItemId]; !ok || current.MerchantId != leg.Merchant.ID {
+				response.MissingItems = append(response.MissingItems, model.MissingItem{
+					MerchantId: leg.Merchant.ID,
+					ItemId:     item.ItemId,
+					Name:       item.Name,
+				})
+				continue
+			}
+			// option that changed since the previous order needs a new selection, so the item is skipped
+			_, reason := selectItemOptions(mapGroups[item.ItemId], item.OptionIds())
+			if !mapItems[item.ItemId].IsAvailable || reason != "" {
+				response.UnavailableItems = append(response.UnavailableItems, model.MissingItem{
+					MerchantId: leg.Merchant.ID,
+					ItemId:     item.ItemId,
+					Name:       item.Name,
+				})
+				continue
+			}
+			options := make([]string, 0, len(item.Options))
+			for _, option := range item.Options {
+				options = append(options, option.OptionId.String())
+			}
+			orderRequest.Items = append(orderRequest.Items, model.OrderRequestItem{
+				ItemId:   item.ItemId.String(),
+				Quantity: item.Quantity,
+				Options:  options,
+			})
+		}
+		if len(orderRequest.Items) == 0 {
+			continue
+		}
+		hasStartingPoint = hasStartingPoint || orderRequest.IsStartingPoint
+		estimateRequest.Orders = append(estimateRequest.Orders, orderRequest)
+	}
+
+	if len(estimateRequest.Orders) == 0 {
+		return response, cerr.New(http.StatusBadRequest, "none of the items in the order are available anymore")
+	}
+	// starting merchant is gone, start from the first remaining merchant
+	if !hasStartingPoint {
+		estimateRequest.Orders[0].IsStartingPoint = true
+	}
+
+	response.EstimateOrdersResponse, err = s.EstimateOrders(ctx, estimateRequest)
+	if err != nil {
+		return response, err
+	}
+
+	return response, nil
+}
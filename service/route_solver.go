@@ -0,0 +1,196 @@
+package service
+
+import (
+	"beli-mang/model"
+	"math"
+)
+
+// heldKarpMaxStops is the most intermediate stops solved exactly, the dp table grows with 2^stops
+const heldKarpMaxStops = 12
+
+// routeEpsilon ignore improvements smaller than float rounding so local search always ends
+const routeEpsilon = 1e-9
+
+// VisitOrder returns the index of the merchants in the order they are visited. The first merchant is
+// always the start and the user is always the last stop, the route in between is exact (Held-Karp)
+// for up to heldKarpMaxStops merchants and improved by 2-opt and Or-opt for more.
+func VisitOrder(merchantsPoint []model.Point, userPoint model.Point) []int {
+	if len(merchantsPoint) == 0 {
+		return nil
+	}
+
+	// node 0 is the start, 1..n-2 are the other merchants and n-1 is the user
+	nodes := append(append([]model.Point{}, merchantsPoint...), userPoint)
+	dist := distanceMatrix(nodes)
+	stops := len(merchantsPoint) - 1
+
+	var seq []int
+	if stops <= heldKarpMaxStops {
+		seq = heldKarp(dist, stops)
+	} else {
+		seq = nearestNeighbor(dist, stops)
+		for improved := true; improved; {
+			improved = twoOpt(dist, seq) || orOpt(dist, seq)
+		}
+	}
+	// drop the user, it is not a merchant
+	return seq[:len(seq)-1]
+}
+
+func distanceMatrix(nodes []model.Point) [][]float64 {
+	dist := make([][]float64, len(nodes))
+	for i := range nodes {
+		dist[i] = make([]float64, len(nodes))
+		for j := range nodes {
+			if i != j {
+				dist[i][j] = haversineDistance(nodes[i].Lat, nodes[i].Lon, nodes[j].Lat, nodes[j].Lon)
+			}
+		}
+	}
+	return dist
+}
+
+// heldKarp returns the shortest path from node 0 through every stop 1..stops to node stops+1
+func heldKarp(dist [][]float64, stops int) []int {
+	end := stops + 1
+	if stops == 0 {
+		return []int{0, end}
+	}
+
+	// cost[mask][j] is the shortest path from the start through the stops in mask ending at stop j+1
+	full := 1<<stops - 1
+	cost := make([][]float64, full+1)
+	parent := make([][]int, full+1)
+	for mask := range cost {
+		cost[mask] = make([]float64, stops)
+		parent[mask] = make([]int, stops)
+		for j := range cost[mask] {
+			cost[mask][j] = math.Inf(1)
+			parent[mask][j] = -1
+		}
+	}
+	for j := 0; j < stops; j++ {
+		cost[1<<j][j] = dist[0][j+1]
+	}
+
+	for mask := 1; mask <= full; mask++ {
+		for j := 0; j < stops; j++ {
+			if mask&(1<<j) == 0 || math.IsInf(cost[mask][j], 1) {
+				continue
+			}
+			for k := 0; k < stops; k++ {
+				if mask&(1<<k) != 0 {
+					continue
+				}
+				next := mask | 1<<k
+				if c := cost[mask][j] + dist[j+1][k+1]; c < cost[next][k] {
+					cost[next][k] = c
+					parent[next][k] = j
+				}
+			}
+		}
+	}
+
+	last := 0
+	best := math.Inf(1)
+	for j := 0; j < stops; j++ {
+		if c := cost[full][j] + dist[j+1][end]; c < best {
+			best = c
+			last = j
+		}
+	}
+
+	// walk back the parents to get the order of the stops
+	seq := make([]int, stops+2)
+	seq[stops+1] = end
+	mask := full
+	for i := stops; i >= 1; i-- {
+		seq[i] = last + 1
+		prev := parent[mask][last]
+		mask &^= 1 << last
+		last = prev
+	}
+	return seq
+}
+
+// nearestNeighbor build a path from node 0 by always going to the closest unvisited stop, the user is last
+func nearestNeighbor(dist [][]float64, stops int) []int {
+	end := stops + 1
+	visited := make([]bool, end)
+	seq := make([]int, 0, stops+2)
+	seq = append(seq, 0)
+	visited[0] = true
+
+	current := 0
+	for len(seq) <= stops {
+		nearest := -1
+		for i := 1; i <= stops; i++ {
+			if !visited[i] && (nearest == -1 || dist[current][i] < dist[current][nearest]) {
+				nearest = i
+			}
+		}
+		visited[nearest] = true
+		seq = append(seq, nearest)
+		current = nearest
+	}
+	return append(seq, end)
+}
+
+// twoOpt reverse the first segment of stops that shorten the path, it reports whether seq changed.
+// the first and last node are never moved.
+func twoOpt(dist [][]float64, seq []int) bool {
+	for i := 1; i < len(seq)-2; i++ {
+		for j := i + 1; j < len(seq)-1; j++ {
+			a, b, c, d := seq[i-1], seq[i], seq[j], seq[j+1]
+			if dist[a][c]+dist[b][d] < dist[a][b]+dist[c][d]-routeEpsilon {
+				for l, r := i, j; l < r; l, r = l+1, r-1 {
+					seq[l], seq[r] = seq[r], seq[l]
+				}
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// orOpt move the first segment of up to 3 stops, as is or reversed, to a place that shorten the path,
+// it reports whether seq changed. the first and last node are never moved.
+func orOpt(dist [][]float64, seq []int) bool {
+	for segLen := 1; segLen <= 3; segLen++ {
+		for i := 1; i+segLen < len(seq); i++ {
+			first, last := seq[i], seq[i+segLen-1]
+			prev, next := seq[i-1], seq[i+segLen]
+			removeGain := dist[prev][first] + dist[last][next] - dist[prev][next]
+
+			rest := make([]int, 0, len(seq)-segLen)
+			rest = append(rest, seq[:i]...)
+			rest = append(rest, seq[i+segLen:]...)
+			for p := 0; p < len(rest)-1; p++ {
+				// inserting back at the same place changes nothing
+				if p == i-1 {
+					continue
+				}
+				x, y := rest[p], rest[p+1]
+				forward := dist[x][first] + dist[last][y] - dist[x][y]
+				reversed := dist[x][last] + dist[first][y] - dist[x][y]
+				if forward >= removeGain-routeEpsilon && reversed >= removeGain-routeEpsilon {
+					continue
+				}
+
+				segment := append([]int{}, seq[i:i+segLen]...)
+				if reversed < forward {
+					for l, r := 0, len(segment)-1; l < r; l, r = l+1, r-1 {
+						segment[l], segment[r] = segment[r], segment[l]
+					}
+				}
+				moved := make([]int, 0, len(seq))
+				moved = append(moved, rest[:p+1]...)
+				moved = append(moved, segment...)
+				moved = append(moved, rest[p+1:]...)
+				copy(seq, moved)
+				return true
+			}
+		}
+	}
+	return false
+}
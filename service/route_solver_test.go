@@ -0,0 +1,137 @@
+package service
+
+import (
+	"beli-mang/model"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// randomPoints returns n points in a ~10 km square around Jakarta
+func randomPoints(rnd *rand.Rand, n int) []model.Point {
+	points := make([]model.Point, n)
+	for i := range points {
+		points[i] = model.Point{Lat: -6.2 + rnd.Float64()*0.1, Lon: 106.8 + rnd.Float64()*0.1}
+	}
+	return points
+}
+
+func pathLength(dist [][]float64, seq []int) float64 {
+	length := 0.0
+	for i := 0; i+1 < len(seq); i++ {
+		length += dist[seq[i]][seq[i+1]]
+	}
+	return length
+}
+
+// bruteForce returns the length of the shortest path from node 0 through every stop to node stops+1
+func bruteForce(dist [][]float64, stops int) float64 {
+	best := math.Inf(1)
+	var permute func(seq []int, used []bool)
+	permute = func(seq []int, used []bool) {
+		if len(seq) == stops+1 {
+			best = math.Min(best, pathLength(dist, append(seq, stops+1)))
+			return
+		}
+		for i := 1; i <= stops; i++ {
+			if used[i] {
+				continue
+			}
+			used[i] = true
+			permute(append(seq, i), used)
+			used[i] = false
+		}
+	}
+	permute([]int{0}, make([]bool, stops+1))
+	return best
+}
+
+// checkPath fails when seq isn't a path from node 0 through every stop once to node stops+1
+func checkPath(t *testing.T, seq []int, stops int) {
+	t.Helper()
+	if len(seq) != stops+2 || seq[0] != 0 || seq[len(seq)-1] != stops+1 {
+		t.Fatalf("path %v must go from 0 to %d through %d stops", seq, stops+1, stops)
+	}
+	seen := make(map[int]bool, stops)
+	for _, node := range seq[1 : len(seq)-1] {
+		if node < 1 || node > stops || seen[node] {
+			t.Fatalf("path %v must visit every stop once", seq)
+		}
+		seen[node] = true
+	}
+}
+
+func TestVisitOrder(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	tests := []struct {
+		name      string
+		merchants int
+	}{
+		{name: "single merchant", merchants: 1},
+		{name: "exact", merchants: 5},
+		{name: "exact limit", merchants: heldKarpMaxStops + 1},
+		{name: "local search", merchants: heldKarpMaxStops + 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points := randomPoints(rnd, tt.merchants+1)
+			merchants, user := points[:tt.merchants], points[tt.merchants]
+
+			order := VisitOrder(merchants, user)
+			if len(order) != tt.merchants || order[0] != 0 {
+				t.Fatalf("VisitOrder() = %v, want every merchant starting with 0", order)
+			}
+			// the user is the node after the merchants, it must end the path
+			checkPath(t, append(append([]int{}, order...), tt.merchants), tt.merchants-1)
+		})
+	}
+
+	if order := VisitOrder(nil, model.Point{}); order != nil {
+		t.Errorf("VisitOrder() without merchant = %v, want nil", order)
+	}
+}
+
+func TestHeldKarpMatchBruteForce(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	for stops := 0; stops <= 6; stops++ {
+		for round := 0; round < 20; round++ {
+			dist := distanceMatrix(randomPoints(rnd, stops+2))
+
+			seq := heldKarp(dist, stops)
+			checkPath(t, seq, stops)
+			if got, want := pathLength(dist, seq), bruteForce(dist, stops); got > want+routeEpsilon {
+				t.Fatalf("%d stops: heldKarp length = %v, want %v", stops, got, want)
+			}
+		}
+	}
+}
+
+func TestLocalSearchImproveNearestNeighbor(t *testing.T) {
+	rnd := rand.New(rand.NewSource(3))
+	for _, stops := range []int{2, 5, 15, 30} {
+		for round := 0; round < 10; round++ {
+			dist := distanceMatrix(randomPoints(rnd, stops+2))
+			seq := nearestNeighbor(dist, stops)
+			checkPath(t, seq, stops)
+			initial := pathLength(dist, seq)
+
+			// every move shorten the path by more than routeEpsilon, so it must end well before this
+			const maxMoves = 100000
+			moves := 0
+			for improved := true; improved; moves++ {
+				if moves == maxMoves {
+					t.Fatalf("%d stops: local search didn't end after %d moves", stops, maxMoves)
+				}
+				before := pathLength(dist, seq)
+				improved = twoOpt(dist, seq) || orOpt(dist, seq)
+				checkPath(t, seq, stops)
+				if after := pathLength(dist, seq); after > before+routeEpsilon {
+					t.Fatalf("%d stops: move made the path longer, %v to %v", stops, before, after)
+				}
+			}
+			if final := pathLength(dist, seq); final > initial+routeEpsilon {
+				t.Errorf("%d stops: local search length = %v, longer than nearest neighbor %v", stops, final, initial)
+			}
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"beli-mang/config"
+	"beli-mang/model"
+	"beli-mang/pkg/callwrapper"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RoutingProvider returns the route through the waypoints in the given order
+type RoutingProvider interface {
+	Route(ctx context.Context, waypoints []model.Point) (model.Route, error)
+}
+
+// NewRoutingProvider returns the OSRM router with straight line fallback,
+// or only the straight line router when no routing url is configured
+func NewRoutingProvider(cfg config.RoutingConfig, logger *zap.Logger) RoutingProvider {
+	if cfg.URL == "" {
+		return NewHaversineRouter()
+	}
+	return NewFallbackRouter(NewOSRMRouter(cfg), NewHaversineRouter(), logger)
+}
+
+type haversineRouter struct{}
+
+// NewHaversineRouter route in straight lines at SpeedKmPerHour, it never fails
+func NewHaversineRouter() RoutingProvider {
+	return haversineRouter{}
+}
+
+func (haversineRouter) Route(_ context.Context, waypoints []model.Point) (model.Route, error) {
+	var route model.Route
+	for i := 0; i+1 < len(waypoints); i++ {
+		distance := routeDistance(waypoints[i : i+2])
+		route.DistanceKm += distance
+		route.LegDurations = append(route.LegDurations, travelTime(distance))
+	}
+	route.Duration = travelTime(route.DistanceKm)
+	return route, nil
+}
+
+type fallbackRouter struct {
+	primary  RoutingProvider
+	fallback RoutingProvider
+	logger   *zap.Logger
+}
+
+// NewFallbackRouter use fallback when primary fails, so estimates keep working while the router is down
+func NewFallbackRouter(primary, fallback RoutingProvider, logger *zap.Logger) RoutingProvider {
+	return &fallbackRouter{
+		primary:  primary,
+		fallback: fallback,
+		logger:   logger,
+	}
+}
+
+func (r *fallbackRouter) Route(ctx context.Context, waypoints []model.Point) (model.Route, error) {
+	route, err := r.primary.Route(ctx, waypoints)
+	if err == nil {
+		return route, nil
+	}
+	// the caller is gone, no need to compute anything
+	if ctx.Err() != nil {
+		return route, ctx.Err()
+	}
+	r.logger.Warn("[routing] primary router failed, using fallback", zap.Error(err))
+	return r.fallback.Route(ctx, waypoints)
+}
+
+var errRouteNotFound = errors.New("no route found")
+
+type osrmRouter struct {
+	baseURL string
+	profile string
+	client  *http.Client
+	cw      *callwrapper.Wrapper
+}
+
+// NewOSRMRouter call the route service of OSRM, or any engine serving the same api (e.g. Valhalla osrm format).
+// Calls go through callwrapper for the timeout and circuit breaker.
+func NewOSRMRouter(cfg config.RoutingConfig) RoutingProvider {
+	return &osrmRouter{
+		baseURL: strings.TrimRight(cfg.URL, "/"),
+		profile: cfg.Profile,
+		client:  &http.Client{},
+		cw: callwrapper.NewWrapperWithoutMetric(callwrapper.Config{
+			CallCtxTimeoutMS: cfg.Timeout.Milliseconds(),
+			Singleflight:     true,
+			HystrixCBConfig: &callwrapper.HystrixCBConfig{
+				ErrorThresholdPercentage: 50,
+				MinRequestThreshold:      10,
+				OnOpenSleepDuration:      10 * time.Second,
+				Timeout:                  cfg.Timeout,
+			},
+		}).WithErrWhitelist(errRouteNotFound),
+	}
+}
+
+type osrmRouteResponse struct {
+	Code   string `json:"code"`
+	Routes []struct {
+		Distance float64 `json:"distance"` // in meters
+		Duration float64 `json:"duration"` // in seconds
+		Legs     []struct {
+			Duration float64 `json:"duration"` // in seconds
+		} `json:"legs"`
+	} `json:"routes"`
+}
+
+func (r *osrmRouter) Route(ctx context.Context, waypoints []model.Point) (model.Route, error) {
+	if len(waypoints) < 2 {
+		return model.Route{}, nil
+	}
+
+	coordinates := make([]string, 0, len(waypoints))
+	for _, p := range waypoints {
+		coordinates = append(coordinates, fmt.Sprintf("%f,%f", p.Lon, p.Lat))
+	}
+	path := strings.Join(coordinates, ";")
+
+	res, err := r.cw.Call(ctx, path, func(ctx context.Context) (interface{}, error) {
+		return r.fetchRoute(ctx, path, len(waypoints))
+	})
+	if err != nil {
+		return model.Route{}, err
+	}
+	return res.(model.Route), nil
+}
+
+func (r *osrmRouter) fetchRoute(ctx context.Context, path string, waypoints int) (model.Route, error) {
+	url := fmt.Sprintf("%s/route/v1/%s/%s?overview=false", r.baseURL, r.profile, path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return model.Route{}, err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return model.Route{}, err
+	}
+	defer resp.Body.Close()
+
+	var body osrmRouteResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return model.Route{}, fmt.Errorf("decode route response with status %d: %w", resp.StatusCode, err)
+	}
+	// NoRoute is about the points, not the router health, so it doesn't trip the breaker
+	if body.Code == "NoRoute" {
+		return model.Route{}, errRouteNotFound
+	}
+	if resp.StatusCode != http.StatusOK || body.Code != "Ok" || len(body.Routes) == 0 {
+		return model.Route{}, fmt.Errorf("route service responded with status %d code %q", resp.StatusCode, body.Code)
+	}
+
+	route := model.Route{
+		DistanceKm: body.Routes[0].Distance / 1000,
+		Duration:   time.Duration(body.Routes[0].Duration * float64(time.Second)),
+	}
+	for _, leg := range body.Routes[0].Legs {
+		route.LegDurations = append(route.LegDurations, time.Duration(leg.Duration*float64(time.Second)))
+	}
+	if len(route.LegDurations) != waypoints-1 {
+		return model.Route{}, fmt.Errorf("route service responded with %d legs for %d waypoints", len(route.LegDurations), waypoints)
+	}
+	return route, nil
+}
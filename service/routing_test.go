@@ -0,0 +1,213 @@
+package service
+
+import (
+	"beli-mang/config"
+	"beli-mang/model"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// osrmStub serve the OSRM route api with the response of respond, it counts the requests
+type osrmStub struct {
+	*httptest.Server
+	requests atomic.Int64
+}
+
+func newOSRMStub(t *testing.T, respond func(w http.ResponseWriter, r *http.Request)) *osrmStub {
+	stub := &osrmStub{}
+	stub.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stub.requests.Add(1)
+		respond(w, r)
+	}))
+	t.Cleanup(stub.Close)
+	return stub
+}
+
+func newTestOSRMRouter(url string) RoutingProvider {
+	return NewOSRMRouter(config.RoutingConfig{URL: url, Profile: "driving", Timeout: time.Second})
+}
+
+var testWaypoints = []model.Point{
+	{Lat: -6.2, Lon: 106.8},
+	{Lat: -6.21, Lon: 106.82},
+	{Lat: -6.25, Lon: 106.85},
+}
+
+func TestOSRMRouterParseRoute(t *testing.T) {
+	var path string
+	stub := newOSRMStub(t, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		fmt.Fprint(w, `{"code":"Ok","routes":[{"distance":4200,"duration":600,"legs":[{"duration":240},{"duration":360}]}]}`)
+	})
+
+	route, err := newTestOSRMRouter(stub.URL).Route(context.Background(), testWaypoints)
+	if err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+	if want := "/route/v1/driving/106.800000,-6.200000;106.820000,-6.210000;106.850000,-6.250000"; path != want {
+		t.Errorf("path = %s, want %s", path, want)
+	}
+	if route.DistanceKm != 4.2 {
+		t.Errorf("DistanceKm = %v, want 4.2", route.DistanceKm)
+	}
+	if route.Duration != 10*time.Minute {
+		t.Errorf("Duration = %v, want 10m", route.Duration)
+	}
+	if len(route.LegDurations) != 2 || route.LegDurations[0] != 4*time.Minute || route.LegDurations[1] != 6*time.Minute {
+		t.Errorf("LegDurations = %v, want [4m 6m]", route.LegDurations)
+	}
+}
+
+func TestOSRMRouterLegCount(t *testing.T) {
+	stub := newOSRMStub(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"code":"Ok","routes":[{"distance":4200,"duration":600,"legs":[{"duration":600}]}]}`)
+	})
+
+	_, err := newTestOSRMRouter(stub.URL).Route(context.Background(), testWaypoints)
+	if err == nil {
+		t.Fatal("Route() error = nil, want an error for 1 leg of 3 waypoints")
+	}
+}
+
+func TestOSRMRouterBreaker(t *testing.T) {
+	tests := []struct {
+		name string
+		// respond is the failing response sent before the router recovers
+		respond     func(w http.ResponseWriter)
+		wantErr     error
+		wantTripped bool
+	}{
+		{
+			name: "NoRoute doesn't trip",
+			respond: func(w http.ResponseWriter) {
+				fmt.Fprint(w, `{"code":"NoRoute","routes":[]}`)
+			},
+			wantErr:     errRouteNotFound,
+			wantTripped: false,
+		},
+		{
+			name: "server error trips",
+			respond: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprint(w, `{"code":"Error"}`)
+			},
+			wantTripped: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var recovered atomic.Bool
+			stub := newOSRMStub(t, func(w http.ResponseWriter, r *http.Request) {
+				if recovered.Load() {
+					fmt.Fprint(w, `{"code":"Ok","routes":[{"distance":1000,"duration":60,"legs":[{"duration":60}]}]}`)
+					return
+				}
+				tt.respond(w)
+			})
+			router := newTestOSRMRouter(stub.URL)
+			waypoints := testWaypoints[:2]
+
+			for i := 0; i < 20; i++ {
+				_, err := router.Route(context.Background(), waypoints)
+				if err == nil {
+					t.Fatal("Route() error = nil while failing")
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Route() error = %v, want %v", err, tt.wantErr)
+				}
+			}
+
+			recovered.Store(true)
+			requests := stub.requests.Load()
+			_, err := router.Route(context.Background(), waypoints)
+			tripped := stub.requests.Load() == requests
+			if tripped != tt.wantTripped {
+				t.Errorf("tripped = %v, want %v (err %v)", tripped, tt.wantTripped, err)
+			}
+			if !tt.wantTripped && err != nil {
+				t.Errorf("Route() error = %v after recovering", err)
+			}
+		})
+	}
+}
+
+// fakeRouter returns err, or a route of one minute per waypoint
+type fakeRouter struct {
+	err error
+}
+
+func (r *fakeRouter) Route(ctx context.Context, waypoints []model.Point) (model.Route, error) {
+	if r.err != nil {
+		return model.Route{}, r.err
+	}
+	return model.Route{Duration: time.Duration(len(waypoints)) * time.Minute}, nil
+}
+
+func TestFallbackRouter(t *testing.T) {
+	straightLine, _ := NewHaversineRouter().Route(context.Background(), testWaypoints)
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name          string
+		ctx           context.Context
+		primaryErr    error
+		want          model.Route
+		wantErr       error
+		wantFallbacks int
+	}{
+		{
+			name: "primary succeed",
+			ctx:  context.Background(),
+			want: model.Route{Duration: 3 * time.Minute},
+		},
+		{
+			name:          "primary fail use straight line",
+			ctx:           context.Background(),
+			primaryErr:    errors.New("router down"),
+			want:          straightLine,
+			wantFallbacks: 1,
+		},
+		{
+			name:       "canceled ctx doesn't fall back",
+			ctx:        canceled,
+			primaryErr: context.Canceled,
+			wantErr:    context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fallback := &countingRouter{RoutingProvider: NewHaversineRouter()}
+			router := NewFallbackRouter(&fakeRouter{err: tt.primaryErr}, fallback, zap.NewNop())
+
+			route, err := router.Route(tt.ctx, testWaypoints)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Route() error = %v, want %v", err, tt.wantErr)
+			}
+			if fallback.calls != tt.wantFallbacks {
+				t.Errorf("fallback calls = %d, want %d", fallback.calls, tt.wantFallbacks)
+			}
+			if route.Duration != tt.want.Duration || route.DistanceKm != tt.want.DistanceKm {
+				t.Errorf("Route() = %+v, want %+v", route, tt.want)
+			}
+		})
+	}
+}
+
+type countingRouter struct {
+	RoutingProvider
+	calls int
+}
+
+func (r *countingRouter) Route(ctx context.Context, waypoints []model.Point) (model.Route, error) {
+	r.calls++
+	return r.RoutingProvider.Route(ctx, waypoints)
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"beli-mang/model"
+	cerr "beli-mang/pkg/customErr"
+	"beli-mang/repo"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ServiceAreaService interface {
+	CreateServiceArea(ctx context.Context, request model.CreateServiceAreaRequest) (model.ServiceArea, error)
+	GetServiceAreas(ctx context.Context) ([]model.ServiceArea, error)
+	DeleteServiceArea(ctx context.Context, id uuid.UUID) error
+}
+
+type serviceAreaSvc struct {
+	repo repo.ServiceAreaRepository
+}
+
+func NewServiceAreaService(repo repo.ServiceAreaRepository) ServiceAreaService {
+	return &serviceAreaSvc{repo: repo}
+}
+
+func (s *serviceAreaSvc) CreateServiceArea(ctx context.Context, request model.CreateServiceAreaRequest) (area model.ServiceArea, err error) {
+	_, geometry, err := model.ParseGeometry(request.Geometry)
+	if err != nil {
+		return area, cerr.New(http.StatusBadRequest, err.Error())
+	}
+	area = model.ServiceArea{
+		ID:        uuid.New(),
+		Name:      request.Name,
+		Geometry:  geometry,
+		CreatedAt: time.Now(),
+	}
+
+	_, err = s.repo.GetServiceAreaByName(ctx, area.Name)
+	if err == nil {
+		return area, cerr.New(http.StatusConflict, "service area already exist")
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return area, err
+	}
+
+	err = s.repo.CreateServiceArea(ctx, area)
+	return area, err
+}
+
+func (s *serviceAreaSvc) GetServiceAreas(ctx context.Context) ([]model.ServiceArea, error) {
+	return s.repo.GetServiceAreas(ctx)
+}
+
+func (s *serviceAreaSvc) DeleteServiceArea(ctx context.Context, id uuid.UUID) error {
+	err := s.repo.DeleteServiceArea(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return cerr.New(http.StatusNotFound, "service area not found")
+	}
+	return err
+}
+
+// serviceAreas is the polygons of every service area, empty means the delivery operates everywhere
+type serviceAreas []model.MultiPolygon
+
+func loadServiceAreas(ctx context.Context, repo repo.ServiceAreaRepository) (serviceAreas, error) {
+	areas, err := repo.GetServiceAreas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	shapes := make(serviceAreas, 0, len(areas))
+	for _, area := range areas {
+		polygons, _, err := model.ParseGeometry(area.Geometry)
+		if err != nil {
+			return nil, fmt.Errorf("service area %s: %w", area.Name, err)
+		}
+		shapes = append(shapes, polygons)
+	}
+	return shapes, nil
+}
+
+// at returns the service area containing the point, ok is also true when there is no service area at all
+func (a serviceAreas) at(lat, long float64) (area model.MultiPolygon, ok bool) {
+	if len(a) == 0 {
+		return nil, true
+	}
+	for _, polygons := range a {
+		if polygons.Contains(lat, long) {
+			return polygons, true
+		}
+	}
+	return nil, false
+}
+
+// checkDeliveryArea make sure the user is in a service area and every merchant deliver to the user,
+// from the same service area and within its delivery radius, so a far merchant can't blow up the route
+func (s *purchaseSvc) checkDeliveryArea(ctx context.Context, merchants []model.Merchant, user model.Point) error {
+	areas, err := loadServiceAreas(ctx, s.serviceAreaRepo)
+	if err != nil {
+		return err
+	}
+	area, ok := areas.at(user.Lat, user.Lon)
+	if !ok {
+		return cerr.New(http.StatusBadRequest, "user location is outside the service area")
+	}
+
+	var outOfRange []model.OutOfRangeMerchant
+	for _, merchant := range merchants {
+		distance := haversineDistance(merchant.Location.Lat, merchant.Location.Long, user.Lat, user.Lon)
+		radius := merchant.DeliveryRadius(s.cfg.DefaultDeliveryRadiusKm)
+		outside := area != nil && !area.Contains(merchant.Location.Lat, merchant.Location.Long)
+		if distance <= radius && !outside {
+			continue
+		}
+		outOfRange = append(outOfRange, model.OutOfRangeMerchant{
+			MerchantId:         merchant.ID,
+			Name:               merchant.Name,
+			DistanceKm:         math.Round(distance*100) / 100,
+			DeliveryRadiusKm:   radius,
+			OutsideServiceArea: outside,
+		})
+	}
+	if len(outOfRange) > 0 {
+		return cerr.NewWithData(http.StatusBadRequest, "some merchants don't deliver to the user location", outOfRange)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"beli-mang/config"
+	"beli-mang/model"
+	cerr "beli-mang/pkg/customErr"
+	"beli-mang/repo"
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// fakeServiceAreaRepo returns its areas, it only implements the reads
+type fakeServiceAreaRepo struct {
+	repo.ServiceAreaRepository
+	areas []model.ServiceArea
+}
+
+func (r *fakeServiceAreaRepo) GetServiceAreas(ctx context.Context) ([]model.ServiceArea, error) {
+	return r.areas, nil
+}
+
+// jakartaArea spans latitude -6.3 to -6.1 and longitude 106.7 to 106.9
+var jakartaArea = model.ServiceArea{
+	Name:     "jakarta",
+	Geometry: json.RawMessage(`{"type":"Polygon","coordinates":[[[106.7,-6.3],[106.9,-6.3],[106.9,-6.1],[106.7,-6.1],[106.7,-6.3]]]}`),
+}
+
+func testMerchant(id string, lat, long float64, radiusKm *float64) model.Merchant {
+	return model.Merchant{
+		ID:               uuid.MustParse(id),
+		Location:         model.Location{Lat: lat, Long: long},
+		DeliveryRadiusKm: radiusKm,
+	}
+}
+
+func TestCheckDeliveryArea(t *testing.T) {
+	wideRadius := 10.0
+	near := testMerchant("00000000-0000-0000-0000-000000000001", -6.21, 106.8, nil)
+	far := testMerchant("00000000-0000-0000-0000-000000000002", -6.15, 106.85, nil)
+	farWide := testMerchant("00000000-0000-0000-0000-000000000003", -6.15, 106.85, &wideRadius)
+	acrossBorder := testMerchant("00000000-0000-0000-0000-000000000004", -6.2, 106.91, nil)
+
+	tests := []struct {
+		name      string
+		areas     []model.ServiceArea
+		merchants []model.Merchant
+		user      model.Point
+		wantErr   string
+		// wantOutOfRange is the id and OutsideServiceArea of every merchant reported
+		wantOutOfRange map[uuid.UUID]bool
+	}{
+		{
+			name:      "near merchant",
+			areas:     []model.ServiceArea{jakartaArea},
+			merchants: []model.Merchant{near},
+			user:      model.Point{Lat: -6.2, Lon: 106.8},
+		},
+		{
+			name:      "merchant radius wider than the default",
+			areas:     []model.ServiceArea{jakartaArea},
+			merchants: []model.Merchant{near, farWide},
+			user:      model.Point{Lat: -6.2, Lon: 106.8},
+		},
+		{
+			name:           "merchant beyond the default radius",
+			areas:          []model.ServiceArea{jakartaArea},
+			merchants:      []model.Merchant{near, far},
+			user:           model.Point{Lat: -6.2, Lon: 106.8},
+			wantErr:        "some merchants don't deliver to the user location",
+			wantOutOfRange: map[uuid.UUID]bool{far.ID: false},
+		},
+		{
+			name:           "merchant in range but outside the user service area",
+			areas:          []model.ServiceArea{jakartaArea},
+			merchants:      []model.Merchant{acrossBorder},
+			user:           model.Point{Lat: -6.2, Lon: 106.89},
+			wantErr:        "some merchants don't deliver to the user location",
+			wantOutOfRange: map[uuid.UUID]bool{acrossBorder.ID: true},
+		},
+		{
+			name:      "user outside every service area",
+			areas:     []model.ServiceArea{jakartaArea},
+			merchants: []model.Merchant{near},
+			user:      model.Point{Lat: -6.0, Lon: 106.8},
+			wantErr:   "user location is outside the service area",
+		},
+		{
+			name:      "no service area only check the radius",
+			merchants: []model.Merchant{acrossBorder},
+			user:      model.Point{Lat: -6.2, Lon: 106.89},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &purchaseSvc{
+				cfg:             &config.Config{DefaultDeliveryRadiusKm: 3},
+				serviceAreaRepo: &fakeServiceAreaRepo{areas: tt.areas},
+			}
+			err := svc.checkDeliveryArea(context.Background(), tt.merchants, tt.user)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("checkDeliveryArea() error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr || cerr.GetCode(err) != 400 {
+				t.Fatalf("checkDeliveryArea() error = %v, want 400 %s", err, tt.wantErr)
+			}
+
+			var outOfRange map[uuid.UUID]bool
+			if data, ok := cerr.GetData(err).([]model.OutOfRangeMerchant); ok {
+				outOfRange = make(map[uuid.UUID]bool, len(data))
+				for _, merchant := range data {
+					outOfRange[merchant.MerchantId] = merchant.OutsideServiceArea
+				}
+			}
+			if !reflect.DeepEqual(outOfRange, tt.wantOutOfRange) {
+				t.Errorf("out of range merchants = %v, want %v", outOfRange, tt.wantOutOfRange)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"beli-mang/config"
+	"beli-mang/model"
+	cerr "beli-mang/pkg/customErr"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+)
+
+// freeFlowTraffic is used when no speed profile apply
+var freeFlowTraffic = model.Traffic{SpeedKmPerHour: SpeedKmPerHour, FeeMultiplier: 1}
+
+// trafficAt returns the traffic in effect at t for a delivery starting at start,
+// it is the first profile whose zone contains start
+func trafficAt(profiles []config.SpeedProfile, start model.Point, t time.Time) model.Traffic {
+	for _, profile := range profiles {
+		if profile.Zone == nil || profile.Zone.Contains(start.Lat, start.Lon) {
+			return profileTraffic(profile, t)
+		}
+	}
+	return freeFlowTraffic
+}
+
+// profileTraffic returns the traffic of the profile slot in effect at t
+func profileTraffic(profile config.SpeedProfile, t time.Time) model.Traffic {
+	traffic := freeFlowTraffic
+	traffic.SpeedProfile = profile.Name
+	if profile.DefaultSpeedKmPerHour > 0 {
+		traffic.SpeedKmPerHour = profile.DefaultSpeedKmPerHour
+	}
+
+	local := t.In(profile.Location())
+	hourOfWeek := int(local.Weekday())*24 + local.Hour()
+	for _, slot := range profile.Slots {
+		if !slot.Contains(hourOfWeek) {
+			continue
+		}
+		traffic.SpeedKmPerHour = slot.SpeedKmPerHour
+		if slot.FeeMultiplier > 0 {
+			traffic.FeeMultiplier = slot.FeeMultiplier
+		}
+		break
+	}
+	return traffic
+}
+
+// applyTraffic scale the route durations from the free flow speed to the traffic speed. Routers estimate
+// at free flow, for road routes the traffic speed relative to SpeedKmPerHour is how much slower it is.
+func applyTraffic(route model.Route, traffic model.Traffic) model.Route {
+	scale := func(d time.Duration) time.Duration {
+		return time.Duration(float64(d) * SpeedKmPerHour / traffic.SpeedKmPerHour)
+	}
+	route.Duration = scale(route.Duration)
+	legs := make([]time.Duration, 0, len(route.LegDurations))
+	for _, leg := range route.LegDurations {
+		legs = append(legs, scale(leg))
+	}
+	route.LegDurations = legs
+	return route
+}
+
+// PreviewSpeedProfiles estimate the travel time and delivery fee of the route under every requested profile,
+// preparation time is left out so only the traffic makes the difference
+func (s *purchaseSvc) PreviewSpeedProfiles(ctx context.Context, request model.SpeedProfilePreviewRequest) (response model.SpeedProfilePreviewResponse, err error) {
+	response.At = time.Now()
+	if request.At != nil {
+		response.At = *request.At
+	}
+
+	profiles := s.cfg.SpeedProfiles
+	if len(request.Profiles) > 0 {
+		byName := make(map[string]config.SpeedProfile, len(s.cfg.SpeedProfiles))
+		for _, profile := range s.cfg.SpeedProfiles {
+			byName[profile.Name] = profile
+		}
+		profiles = make([]config.SpeedProfile, 0, len(request.Profiles))
+		for _, name := range request.Profiles {
+			profile, ok := byName[name]
+			if !ok {
+				return response, cerr.New(http.StatusBadRequest, fmt.Sprintf("speed profile %s not found", name))
+			}
+			profiles = append(profiles, profile)
+		}
+	}
+
+	merchants := make([]model.Merchant, 0, len(request.Merchants))
+	for _, location := range request.Merchants {
+		merchants = append(merchants, model.Merchant{Location: location})
+	}
+	start := model.Point{Lat: request.Merchants[0].Lat, Lon: request.Merchants[0].Long}
+	end := model.Point{Lat: request.UserLocation.Lat, Lon: request.UserLocation.Long}
+	route, _, _, err := s.planRoute(ctx, merchants, nil, end, freeFlowTraffic)
+	if err != nil {
+		return response, err
+	}
+	response.RouteDistanceInKm = route.DistanceKm
+
+	inEffect := trafficAt(s.cfg.SpeedProfiles, start, response.At)
+	preview := func(traffic model.Traffic) model.SpeedProfilePreview {
+		return model.SpeedProfilePreview{
+			Traffic:                        traffic,
+			InEffect:                       traffic.SpeedProfile == inEffect.SpeedProfile,
+			EstimatedDeliveryTimeInMinutes: int(math.Round(applyTraffic(route, traffic).Duration.Minutes())),
+			DeliveryFee:                    calculateFees(s.cfg.Fee, traffic, route.DistanceKm, len(merchants), 0).DeliveryFee,
+		}
+	}
+	response.Previews = append(response.Previews, preview(freeFlowTraffic))
+	for _, profile := range profiles {
+		response.Previews = append(response.Previews, preview(profileTraffic(profile, response.At)))
+	}
+	return response, nil
+}